@@ -0,0 +1,12 @@
+//go:build builtin_ansible || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/plugins/exporters/ansible/ansible"
+)
+
+func init() {
+	exporter.Register("ansible", exporter.HandlerFunc(ansible.Handle), ansible.Options()...)
+}
@@ -0,0 +1,12 @@
+//go:build builtin_awssecrets || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/plugins/providers/awssecrets/awssecrets"
+	"github.com/fr0stylo/sfx/provider"
+)
+
+func init() {
+	provider.Register("awssecrets", awssecrets.New(), awssecrets.Options()...)
+}
@@ -0,0 +1,12 @@
+//go:build builtin_awsssm || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/plugins/providers/awsssm/awsssm"
+	"github.com/fr0stylo/sfx/provider"
+)
+
+func init() {
+	provider.Register("awsssm", awsssm.New(), awsssm.Options()...)
+}
@@ -0,0 +1,12 @@
+//go:build builtin_azurevault || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/plugins/providers/azurevault/azurevault"
+	"github.com/fr0stylo/sfx/provider"
+)
+
+func init() {
+	provider.Register("azurevault", azurevault.New(), azurevault.Options()...)
+}
@@ -0,0 +1,12 @@
+//go:build builtin_env || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/plugins/exporters/env/env"
+)
+
+func init() {
+	exporter.Register("env", exporter.HandlerFunc(env.Handle), env.Options()...)
+}
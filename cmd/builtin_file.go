@@ -0,0 +1,12 @@
+//go:build builtin_file || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/plugins/providers/file/file"
+	"github.com/fr0stylo/sfx/provider"
+)
+
+func init() {
+	provider.Register("file", file.Handler(), file.Options()...)
+}
@@ -0,0 +1,12 @@
+//go:build builtin_files || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/plugins/exporters/files/files"
+)
+
+func init() {
+	exporter.Register("files", exporter.HandlerFunc(files.Handle), files.Options()...)
+}
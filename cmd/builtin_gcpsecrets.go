@@ -0,0 +1,12 @@
+//go:build builtin_gcpsecrets || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/plugins/providers/gcpsecrets/gcpsecrets"
+	"github.com/fr0stylo/sfx/provider"
+)
+
+func init() {
+	provider.Register("gcpsecrets", gcpsecrets.New(), gcpsecrets.Options()...)
+}
@@ -0,0 +1,12 @@
+//go:build builtin_k8ssecret || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/plugins/exporters/k8ssecret/k8ssecret"
+)
+
+func init() {
+	exporter.Register("k8ssecret", exporter.HandlerFunc(k8ssecret.Handle), k8ssecret.Options()...)
+}
@@ -0,0 +1,12 @@
+//go:build builtin_shell || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/plugins/exporters/shell/shell"
+)
+
+func init() {
+	exporter.Register("shell", exporter.HandlerFunc(shell.Handle), shell.Options()...)
+}
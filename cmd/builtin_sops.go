@@ -0,0 +1,12 @@
+//go:build builtin_sops || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/plugins/providers/sops/sops"
+	"github.com/fr0stylo/sfx/provider"
+)
+
+func init() {
+	provider.Register("sops", sops.Handler(), sops.Options()...)
+}
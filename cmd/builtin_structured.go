@@ -0,0 +1,12 @@
+//go:build builtin_structured || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/plugins/exporters/structured/structured"
+)
+
+func init() {
+	exporter.Register("structured", exporter.HandlerFunc(structured.Handle), structured.Options()...)
+}
@@ -0,0 +1,12 @@
+//go:build builtin_template || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/plugins/exporters/template/template"
+)
+
+func init() {
+	exporter.Register("template", exporter.HandlerFunc(template.Handle), template.Options()...)
+}
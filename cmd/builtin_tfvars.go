@@ -0,0 +1,12 @@
+//go:build builtin_tfvars || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/plugins/exporters/tfvars/tfvars"
+)
+
+func init() {
+	exporter.Register("tfvars", exporter.HandlerFunc(tfvars.Handle), tfvars.Options()...)
+}
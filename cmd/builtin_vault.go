@@ -0,0 +1,12 @@
+//go:build builtin_vault || builtin_all
+
+package main
+
+import (
+	"github.com/fr0stylo/sfx/plugins/providers/vault/vault"
+	"github.com/fr0stylo/sfx/provider"
+)
+
+func init() {
+	provider.Register("vault", vault.New(), vault.Options()...)
+}
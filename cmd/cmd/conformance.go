@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fr0stylo/sfx/config"
+	"github.com/fr0stylo/sfx/internal/client"
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// conformanceRef is sent to providers when no secret in the configuration uses them.
+const conformanceRef = "conformance://probe"
+
+func newPluginsConformanceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "conformance <name|path>",
+		Short: "Check that a plugin binary behaves correctly under the host's process model",
+		Long: "Start a configured plugin, or the plugin binary at path, and drive it through a scripted scenario: " +
+			"handshake, several requests on one process, a request without options, an oversized request, " +
+			"EOF and a malformed request. Error responses are acceptable; crashes, hangs and missing responses are not. " +
+			"Providers of configured plugins are probed with the first secret that uses them unless --ref is given.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.Flags()
+			kind, err := flags.GetString("kind")
+			if err != nil {
+				return err
+			}
+			protocol, err := flags.GetString("protocol")
+			if err != nil {
+				return err
+			}
+			timeout, err := flags.GetDuration("timeout")
+			if err != nil {
+				return err
+			}
+
+			spec, probe, err := conformanceTarget(args[0], kind)
+			if err != nil {
+				return err
+			}
+			if protocol != "" {
+				spec.Protocol = protocol
+			}
+			probe.Timeout = timeout
+
+			if flags.Changed("ref") {
+				if probe.Ref, err = flags.GetString("ref"); err != nil {
+					return err
+				}
+			}
+			if flags.Changed("option") {
+				raw, err := flags.GetString("option")
+				if err != nil {
+					return err
+				}
+				options, err := parseOptionOverride(raw)
+				if err != nil {
+					return err
+				}
+				if probe.Options, err = rpc.MarshalOptions(options); err != nil {
+					return err
+				}
+			}
+
+			return runConformance(cmd.Context(), cmd.OutOrStdout(), spec, probe)
+		},
+	}
+
+	cmd.Flags().String("kind", "", "Plugin kind (provider or exporter) when the name is ambiguous")
+	cmd.Flags().String("protocol", "", "Wire protocol to test (protobuf or jsonl); defaults to the configured one")
+	cmd.Flags().String("ref", conformanceRef, "Ref sent to a provider")
+	cmd.Flags().String("option", "", "Options sent with each request (key=value or key=value;key=value)")
+	cmd.Flags().Duration("timeout", 10*time.Second, "Time allowed for each step")
+
+	return cmd
+}
+
+// conformanceTarget resolves arg to a configured plugin or, failing that, to a
+// plugin binary on disk, and picks the probe sent to it.
+func conformanceTarget(arg, kind string) (client.Spec, client.ConformanceProbe, error) {
+	probe := client.ConformanceProbe{
+		Ref:    conformanceRef,
+		Values: map[string][]byte{"SFX_CONFORMANCE": []byte("value")},
+	}
+
+	if cfg, err := config.Load(); err == nil {
+		if e, err := findPlugin(configuredPlugins(cfg), kind, arg); err == nil {
+			spec, err := pluginSpec(e.Kind, e.Name, e.Plugin, cfg.RequirePinned)
+			if err != nil {
+				return client.Spec{}, probe, fmt.Errorf("%s %q: %w", e.Kind, e.Name, err)
+			}
+			if !isBinaryPath(spec.Path) {
+				return client.Spec{}, probe, fmt.Errorf("%s %q runs at %s; conformance only drives plugin binaries", e.Kind, e.Name, spec.Path)
+			}
+
+			for _, name := range sortedKeys(cfg.Secrets) {
+				if secret := cfg.Secrets[name]; e.Kind == "provider" && secret.Provider == e.Name {
+					probe.Ref = secret.Ref
+					if probe.Options, err = rpc.MarshalOptions(secret.ProviderOptions); err != nil {
+						return client.Spec{}, probe, err
+					}
+					break
+				}
+			}
+			return spec, probe, nil
+		}
+	}
+
+	if _, err := os.Stat(arg); err != nil {
+		return client.Spec{}, probe, fmt.Errorf("%q is neither a configured plugin nor a plugin binary: %w", arg, err)
+	}
+	if err := client.CheckExecutable(arg); err != nil {
+		return client.Spec{}, probe, err
+	}
+	return client.Spec{Name: filepath.Base(arg), Kind: kind, Path: arg}, probe, nil
+}
+
+// runConformance prints the outcome of every conformance step and returns an
+// error when any of them failed.
+func runConformance(ctx context.Context, out io.Writer, spec client.Spec, probe client.ConformanceProbe) error {
+	failures := 0
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tSTATUS\tDETAIL")
+	for _, r := range client.Conformance(ctx, spec, probe) {
+		status, detail := "ok", r.Detail
+		if r.Err != nil {
+			status, detail = "FAIL", r.Err.Error()
+			failures++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Step, status, detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("conformance failed: %d violation(s) found", failures)
+	}
+
+	_, err := fmt.Fprintf(out, "\n%s conforms to the plugin protocol\n", spec.Path)
+	return err
+}
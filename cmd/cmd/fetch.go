@@ -6,16 +6,19 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"gopkg.in/yaml.v3"
 
 	"github.com/fr0stylo/sfx/config"
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/internal/builtin"
 	"github.com/fr0stylo/sfx/internal/client"
+	"github.com/fr0stylo/sfx/internal/outdir"
 	"github.com/fr0stylo/sfx/internal/rpc"
 )
 
@@ -33,35 +36,47 @@ func newFetchCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringP("output", "o", "env", "Output type (env, tfvars, template, shell, k8ssecret, ansible)")
+	cmd.Flags().StringP("output", "o", "env", "Output type (env, tfvars, template, shell, k8ssecret, ansible, files, structured)")
 	// TODO: Find out how to do this properly
 	//cmd.Flags().String("output-option", "", "Override output options (key=value or key=value;key=value)")
 	cmd.Flags().String("output-template", "", "Output options (key=value or key=value;key=value)")
+	cmd.Flags().String("out-dir", "", "Directory to write files returned by the exporter to")
 
 	Must(viper.BindPFlag("output.type", cmd.Flags().Lookup("output")))
 	// TODO: Find out how to do this properly
 	//Must(viper.BindPFlag("output.options", cmd.Flags().Lookup("output-option")))
 	Must(viper.BindPFlag("output.template", cmd.Flags().Lookup("output-template")))
+	Must(viper.BindPFlag("output.dir", cmd.Flags().Lookup("out-dir")))
 
 	return cmd
 }
 
 func runFetch(ctx context.Context, out io.Writer) error {
-	slog.SetLogLoggerLevel(slog.LevelInfo)
-
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("load configuration: %w", err)
 	}
 
+	defer func() {
+		if err := client.Shutdown(); err != nil {
+			slog.Warn("plugin shutdown", "error", err)
+		}
+	}()
+
 	secrets := map[string][]byte{}
+	metadata := map[string]map[string]string{}
+	types := map[string]string{}
 	for name, secret := range cfg.Secrets {
-		providerPath, ok := cfg.Providers[secret.Provider]
-		if !ok || providerPath == "" {
+		providerCfg, ok := cfg.Providers[secret.Provider]
+		if !ok {
 			return fmt.Errorf("provider %q not configured", secret.Provider)
 		}
 
-		val, err := fetchSecret(ctx, providerPath, secret.Ref, secret.ProviderOptions)
+		spec, err := pluginSpec("provider", secret.Provider, providerCfg, cfg.RequirePinned)
+		if err != nil {
+			return err
+		}
+		val, meta, err := fetchSecret(client.WithSecret(ctx, name), spec, secret.Ref, secret.ProviderOptions)
 		if err != nil {
 			return fmt.Errorf("fetch %q: %w", name, err)
 		}
@@ -70,14 +85,28 @@ func runFetch(ctx context.Context, out io.Writer) error {
 			slog.Warn("duplicate secret name", "name", name)
 		}
 
+		if secret.Type != "" {
+			// Catch values that do not match their declared type here rather
+			// than in whichever exporter first parses them.
+			if _, err := exporter.ParseValue(secret.Type, val); err != nil {
+				return fmt.Errorf("secret %q: %w", name, err)
+			}
+			types[name] = secret.Type
+		}
+
 		secrets[name] = val
+		metadata[name] = withSource(meta, secret.Provider, secret.Ref)
 	}
 
 	targetOutput := cfg.Output.Type
-	exporterPath := cfg.Exporters[targetOutput]
-	if exporterPath == "" {
+	exporterCfg, ok := cfg.Exporters[targetOutput]
+	if !ok {
 		return fmt.Errorf("exporter for type %q not configured", targetOutput)
 	}
+	exporterSpec, err := pluginSpec("exporter", targetOutput, exporterCfg, cfg.RequirePinned)
+	if err != nil {
+		return err
+	}
 
 	options := cfg.Output.Options
 	rawOption := strings.TrimSpace(viper.GetString("fetch.option"))
@@ -94,45 +123,67 @@ func runFetch(ctx context.Context, out io.Writer) error {
 		}
 	}
 
-	data, err := formatSecrets(ctx, exporterPath, secrets, options)
+	req := &rpc.ExportRequest{Values: secrets, Keys: cfg.SecretOrder, Metadata: rpc.WrapMetadata(metadata), Types: types}
+	resp, err := formatSecrets(ctx, exporterSpec, req, options)
 	if err != nil {
 		return fmt.Errorf("format output: %w", err)
 	}
 
-	if _, err := io.Copy(out, bytes.NewReader(data)); err != nil {
+	if files := resp.GetFiles(); len(files) > 0 || resp.GetPrune() {
+		if err := writeFiles(cfg.Output.Dir, files, resp.GetPrune()); err != nil {
+			return fmt.Errorf("exporter %q: %w", targetOutput, err)
+		}
+	}
+
+	if _, err := io.Copy(out, bytes.NewReader(resp.GetPayload())); err != nil {
 		return fmt.Errorf("write output: %w", err)
 	}
 
 	return nil
 }
 
-func fetchSecret(ctx context.Context, path, ref string, options map[string]any) ([]byte, error) {
-	opts, err := marshalOptions(options)
+func fetchSecret(ctx context.Context, spec client.Spec, ref string, options map[string]any) ([]byte, map[string]string, error) {
+	opts, err := rpc.MarshalOptions(options)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	req := &rpc.SecretRequest{Ref: ref, Options: opts}
 	var resp rpc.SecretResponse
-	if err := client.CallContext(ctx, path, req, &resp); err != nil {
-		return nil, err
+	if err := client.CallContext(ctx, spec, req, &resp); err != nil {
+		return nil, nil, err
 	}
 
 	if resp.Error != "" {
-		return nil, fmt.Errorf("provider error: %s", resp.Error)
+		return nil, nil, fmt.Errorf("provider error: %s", resp.Error)
 	}
-	return resp.Value, nil
+	return resp.Value, resp.Metadata, nil
 }
 
-func formatSecrets(ctx context.Context, path string, data map[string][]byte, options map[string]any) ([]byte, error) {
-	opts, err := marshalOptions(options)
+// withSource records which provider and ref a secret came from, overriding
+// whatever the provider itself reported under those keys.
+func withSource(meta map[string]string, provider, ref string) map[string]string {
+	out := make(map[string]string, len(meta)+2)
+	for k, v := range meta {
+		if v != "" {
+			out[k] = v
+		}
+	}
+	out[rpc.MetaProvider] = provider
+	out[rpc.MetaRef] = ref
+	return out
+}
+
+// formatSecrets sends req to the exporter with options encoded into it.
+func formatSecrets(ctx context.Context, spec client.Spec, req *rpc.ExportRequest, options map[string]any) (*rpc.ExportResponse, error) {
+	opts, err := rpc.MarshalOptions(options)
 	if err != nil {
 		return nil, err
 	}
+	req.Options = opts
 
-	req := &rpc.ExportRequest{Values: data, Options: opts}
 	var resp rpc.ExportResponse
-	if err := client.CallContext(ctx, path, req, &resp); err != nil {
+	if err := client.CallContext(ctx, spec, req, &resp); err != nil {
 		return nil, err
 	}
 
@@ -140,14 +191,95 @@ func formatSecrets(ctx context.Context, path string, data map[string][]byte, opt
 		return nil, fmt.Errorf("exporter error: %s", resp.Error)
 	}
 
-	return resp.GetPayload(), nil
+	return &resp, nil
 }
 
-func marshalOptions(options map[string]any) ([]byte, error) {
-	if len(options) == 0 {
-		return nil, nil
+// writeFiles writes the files returned by an exporter under dir. With prune,
+// files from an earlier pruning export that are no longer returned are removed.
+func writeFiles(dir string, files []*rpc.File, prune bool) error {
+	if strings.TrimSpace(dir) == "" {
+		return fmt.Errorf("returned %d files; set --out-dir (or output.dir) to write them", len(files))
+	}
+
+	out := make([]outdir.File, len(files))
+	for i, f := range files {
+		out[i] = outdir.File{Path: f.GetPath(), Content: f.GetContent(), Mode: fs.FileMode(f.GetMode())}
 	}
-	return yaml.Marshal(options)
+	write := outdir.Write
+	if prune {
+		write = outdir.Replace
+	}
+	if err := write(dir, out); err != nil {
+		return err
+	}
+	slog.Debug("wrote exporter files", "dir", dir, "count", len(files))
+	return nil
+}
+
+// pluginSpec builds the client spec for a configured plugin. Plugins without a path
+// are looked up on the search path, falling back to a builtin of the same name.
+// With requirePinned, plugin binaries without a sha256 pin are refused.
+func pluginSpec(kind, name string, p config.Plugin, requirePinned bool) (client.Spec, error) {
+	path := strings.TrimSpace(p.Path)
+	if path == "" {
+		resolved, err := client.Resolve(kind, name)
+		switch {
+		case err == nil:
+			path = resolved
+		case isBuiltin(kind, name):
+			path = builtin.Path(name)
+		default:
+			return client.Spec{}, err
+		}
+	}
+
+	if requirePinned && p.SHA256 == "" && isBinaryPath(path) {
+		return client.Spec{}, fmt.Errorf("%s %q is not pinned; run `sfx plugins pin` or drop --require-pinned", kind, name)
+	}
+
+	if p.Limits.AddressSpaceMB > config.MaxAddressSpaceMB {
+		return client.Spec{}, fmt.Errorf("%s %q: limits.address_space_mb %d exceeds %d", kind, name, p.Limits.AddressSpaceMB, uint64(config.MaxAddressSpaceMB))
+	}
+
+	mounts := make([]client.Mount, 0, len(p.Mounts))
+	for _, raw := range p.Mounts {
+		m, err := config.ParseMount(raw)
+		if err != nil {
+			return client.Spec{}, fmt.Errorf("%s %q: %w", kind, name, err)
+		}
+		mounts = append(mounts, client.Mount{Host: m.Host, Guest: m.Guest, ReadOnly: m.ReadOnly})
+	}
+
+	return client.Spec{
+		Name:     name,
+		Kind:     kind,
+		Path:     path,
+		Mode:     client.Mode(p.Mode),
+		Protocol: p.Protocol,
+		SHA256:   p.SHA256,
+		EnvAllow: p.Env.Allow,
+		EnvSet:   p.Env.Set,
+		Dir:      p.Dir,
+		Limits: client.Limits{
+			CPU:          p.Limits.CPU,
+			AddressSpace: p.Limits.AddressSpaceMB << 20,
+			OpenFiles:    p.Limits.OpenFiles,
+			NoNewPrivs:   p.Limits.NoNewPrivs,
+		},
+		Mounts: mounts,
+	}, nil
+}
+
+// isBinaryPath reports whether path names a plugin binary rather than a builtin
+// or remote plugin.
+func isBinaryPath(path string) bool {
+	_, isBuiltin := builtin.ParsePath(path)
+	return !isBuiltin && !rpc.IsRemote(path)
+}
+
+func isBuiltin(kind, name string) bool {
+	_, ok := builtin.Lookup(kind, name)
+	return ok
 }
 
 func parseOptionOverride(raw string) (map[string]any, error) {
@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/fr0stylo/sfx/config"
+	"github.com/fr0stylo/sfx/internal/builtin"
+	"github.com/fr0stylo/sfx/internal/client"
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+func init() {
+	RegisterSubCommand(newPluginsCommand())
+}
+
+func newPluginsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plugins",
+		Short: "Inspect configured providers and exporters",
+		Long:  "List, inspect and probe the provider and exporter plugins referenced by the configuration.",
+	}
+
+	cmd.AddCommand(newPluginsListCommand(), newPluginsInspectCommand(), newPluginsPinCommand(), newPluginsConformanceCommand())
+
+	return cmd
+}
+
+func newPluginsListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List plugins with their resolved path, version and handshake status",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("load configuration: %w", err)
+			}
+
+			return listPlugins(cmd.Context(), cmd.OutOrStdout(), knownPlugins(cfg), requiredPlugins(cfg), cfg.RequirePinned)
+		},
+	}
+}
+
+func newPluginsInspectCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect <name>",
+		Short: "Show a plugin's ref format and option schema",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("load configuration: %w", err)
+			}
+
+			kind, err := cmd.Flags().GetString("kind")
+			if err != nil {
+				return err
+			}
+
+			entry, err := findPlugin(configuredPlugins(cfg), kind, args[0])
+			if err != nil {
+				return err
+			}
+
+			return inspectPlugin(cmd.Context(), cmd.OutOrStdout(), entry, cfg.RequirePinned)
+		},
+	}
+
+	cmd.Flags().String("kind", "", "Plugin kind (provider or exporter) when the name is ambiguous")
+
+	return cmd
+}
+
+func newPluginsPinCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pin [name...]",
+		Short: "Record the sha256 of plugin binaries in .sfx.yaml",
+		Long: "Hash the resolved binary of each named plugin and store the digests as sha256 pins in the " +
+			"configuration file. Without names, every plugin declared in the file is pinned, together with the " +
+			"providers secrets use and the output exporter; declared plugins that are not installed and not used " +
+			"are skipped.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("load configuration: %w", err)
+			}
+
+			kind, err := cmd.Flags().GetString("kind")
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				return pinPlugins(cmd.OutOrStdout(), viper.ConfigFileUsed(), requiredPlugins(cfg), usedPlugins(cfg))
+			}
+
+			var entries []pluginEntry
+			for _, name := range args {
+				e, err := findPlugin(configuredPlugins(cfg), kind, name)
+				if err != nil {
+					return err
+				}
+				entries = append(entries, e)
+			}
+			return pinPlugins(cmd.OutOrStdout(), viper.ConfigFileUsed(), entries, entries)
+		},
+	}
+
+	cmd.Flags().String("kind", "", "Plugin kind (provider or exporter) when a name is ambiguous")
+
+	return cmd
+}
+
+// pluginEntry is a configured provider or exporter.
+type pluginEntry struct {
+	Kind   string
+	Name   string
+	Plugin config.Plugin
+}
+
+func configuredPlugins(cfg config.Config) []pluginEntry {
+	var entries []pluginEntry
+	for _, name := range sortedKeys(cfg.Providers) {
+		entries = append(entries, pluginEntry{Kind: "provider", Name: name, Plugin: cfg.Providers[name]})
+	}
+	for _, name := range sortedKeys(cfg.Exporters) {
+		entries = append(entries, pluginEntry{Kind: "exporter", Name: name, Plugin: cfg.Exporters[name]})
+	}
+	return entries
+}
+
+// usedPlugins returns the providers referenced by secrets and the output exporter.
+func usedPlugins(cfg config.Config) []pluginEntry {
+	seen := map[string]bool{}
+	var entries []pluginEntry
+	for _, name := range sortedKeys(cfg.Secrets) {
+		provider := cfg.Secrets[name].Provider
+		if seen[provider] {
+			continue
+		}
+		seen[provider] = true
+		entries = append(entries, pluginEntry{Kind: "provider", Name: provider, Plugin: cfg.Providers[provider]})
+	}
+	return append(entries, pluginEntry{Kind: "exporter", Name: cfg.Output.Type, Plugin: cfg.Exporters[cfg.Output.Type]})
+}
+
+// knownPlugins returns every configured plugin, bundled defaults included,
+// followed by the used plugins that are not declared, such as providers
+// resolved from the search path.
+func knownPlugins(cfg config.Config) []pluginEntry {
+	return appendUsed(configuredPlugins(cfg), cfg)
+}
+
+// requiredPlugins returns the plugins the configuration depends on: those
+// declared in the configuration file and those secrets or the output use.
+// Bundled plugins the file never mentions are left out.
+func requiredPlugins(cfg config.Config) []pluginEntry {
+	var entries []pluginEntry
+	for _, e := range configuredPlugins(cfg) {
+		if viper.InConfig(e.Kind + "s." + e.Name) {
+			entries = append(entries, e)
+		}
+	}
+	return appendUsed(entries, cfg)
+}
+
+// appendUsed appends the used plugins missing from entries.
+func appendUsed(entries []pluginEntry, cfg config.Config) []pluginEntry {
+	seen := map[string]bool{}
+	for _, e := range entries {
+		seen[probeKey(e.Kind, e.Name)] = true
+	}
+	for _, e := range usedPlugins(cfg) {
+		if !seen[probeKey(e.Kind, e.Name)] {
+			seen[probeKey(e.Kind, e.Name)] = true
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func findPlugin(entries []pluginEntry, kind, name string) (pluginEntry, error) {
+	var matches []pluginEntry
+	for _, e := range entries {
+		if e.Name == name && (kind == "" || e.Kind == kind) {
+			matches = append(matches, e)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return pluginEntry{}, fmt.Errorf("plugin %q not configured", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return pluginEntry{}, fmt.Errorf("plugin %q is both a provider and an exporter; use --kind", name)
+	}
+}
+
+// probePlugin resolves the plugin binary and performs the handshake.
+func probePlugin(ctx context.Context, e pluginEntry, requirePinned bool) (client.Spec, *rpc.Handshake, error) {
+	spec, err := pluginSpec(e.Kind, e.Name, e.Plugin, requirePinned)
+	if err != nil {
+		return client.Spec{}, nil, err
+	}
+
+	hs, err := client.Describe(ctx, spec)
+	return spec, hs, err
+}
+
+// pinPlugins hashes the resolved binaries of entries and writes the pins to configFile.
+// Builtin and remote plugins have no binary to hash and are skipped, as are
+// entries missing from used that are not installed.
+func pinPlugins(out io.Writer, configFile string, entries, used []pluginEntry) error {
+	isUsed := map[string]bool{}
+	for _, e := range used {
+		isUsed[probeKey(e.Kind, e.Name)] = true
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tKIND\tPATH\tSHA256")
+
+	var pins []config.Pin
+	for _, e := range entries {
+		spec, err := pluginSpec(e.Kind, e.Name, e.Plugin, false)
+		if errors.Is(err, client.ErrNotFound) && !isUsed[probeKey(e.Kind, e.Name)] {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Name, e.Kind, "-", "not installed (skipped)")
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", e.Kind, e.Name, err)
+		}
+		if !isBinaryPath(spec.Path) {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Name, e.Kind, spec.Path, "not a binary (skipped)")
+			continue
+		}
+
+		sum, err := client.FileSHA256(spec.Path)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", e.Kind, e.Name, err)
+		}
+		pins = append(pins, config.Pin{Kind: e.Kind, Name: e.Name, SHA256: sum})
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Name, e.Kind, spec.Path, sum)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(pins) == 0 {
+		return nil
+	}
+	if err := config.WritePins(configFile, pins); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "wrote %d pin(s) to %s\n", len(pins), configFile)
+	return err
+}
+
+// listPlugins probes entries and prints their status. Only failures of the
+// required plugins count; bundled plugins that are neither declared nor used
+// are reported as not installed when they cannot be resolved.
+func listPlugins(ctx context.Context, out io.Writer, entries, required []pluginEntry, requirePinned bool) error {
+	isRequired := map[string]bool{}
+	for _, e := range required {
+		isRequired[probeKey(e.Kind, e.Name)] = true
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tKIND\tPATH\tVERSION\tSTATUS")
+
+	failures := 0
+	for _, e := range entries {
+		spec, hs, err := probePlugin(ctx, e, requirePinned)
+
+		status := "ok"
+		switch {
+		case err == nil:
+		case isRequired[probeKey(e.Kind, e.Name)]:
+			status = err.Error()
+			failures++
+		case errors.Is(err, client.ErrNotFound):
+			status = "not installed"
+		default:
+			status = err.Error()
+		}
+		path := spec.Path
+		if path == "" {
+			path = "-"
+		}
+		version := valueOr(hs.GetVersion(), "unknown")
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Name, e.Kind, path, version, status)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d required plugin(s) failed", failures, len(required))
+	}
+	return nil
+}
+
+func inspectPlugin(ctx context.Context, out io.Writer, e pluginEntry, requirePinned bool) error {
+	spec, hs, err := probePlugin(ctx, e, requirePinned)
+	if err != nil {
+		return fmt.Errorf("%s %q: %w", e.Kind, e.Name, err)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", e.Name)
+	fmt.Fprintf(tw, "Kind:\t%s\n", e.Kind)
+	fmt.Fprintf(tw, "Path:\t%s\n", spec.Path)
+	fmt.Fprintf(tw, "Version:\t%s\n", valueOr(hs.GetVersion(), "unknown"))
+	fmt.Fprintf(tw, "Protocol:\t%d\n", hs.GetProtocolVersion())
+	fmt.Fprintf(tw, "Mode:\t%s\n", effectiveMode(spec, hs))
+	if e.Kind == "provider" {
+		fmt.Fprintf(tw, "Ref format:\t%s\n", valueOr(hs.GetRefFormat(), "not declared"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(hs.GetOptionsSchema()) == 0 {
+		_, err := fmt.Fprintln(out, "Options:  not declared")
+		return err
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, hs.GetOptionsSchema(), "", "  "); err != nil {
+		return fmt.Errorf("decode option schema: %w", err)
+	}
+	_, err = fmt.Fprintf(out, "Options:\n%s\n", pretty.String())
+	return err
+}
+
+// effectiveMode describes how the host runs the plugin: the configured mode
+// wins over the one the plugin advertises in its handshake.
+func effectiveMode(spec client.Spec, hs *rpc.Handshake) string {
+	_, isBuiltin := builtin.ParsePath(spec.Path)
+	switch {
+	case isBuiltin:
+		return "in-process (builtin)"
+	case rpc.IsRemote(spec.Path):
+		return "remote"
+	case spec.Mode != client.ModeAuto:
+		return fmt.Sprintf("%s (configured)", spec.Mode)
+	case hs.GetMode() != "":
+		return fmt.Sprintf("%s (advertised by plugin)", hs.GetMode())
+	default:
+		return "persistent (default)"
+	}
+}
+
+func valueOr(v, fallback string) string {
+	if v == "" {
+		return fallback
+	}
+	return v
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 
@@ -14,12 +15,26 @@ var rootCmd = &cobra.Command{
 	Use:   "sfx",
 	Short: "Secret fetcher and exporter CLI",
 	Long:  "sfx is a pluggable CLI that fetches secrets from multiple providers and renders them through exporters.",
+	PersistentPreRunE: func(*cobra.Command, []string) error {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(viper.GetString("log_level"))); err != nil {
+			return fmt.Errorf("invalid log level: %w", err)
+		}
+		slog.SetLogLoggerLevel(level)
+		return nil
+	},
 }
 
 func init() {
 	viper.SetEnvPrefix("SFX")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
+
+	rootCmd.PersistentFlags().String("log-level", "info", "Log level (debug, info, warn, error); applies to plugin logs too")
+	Must(viper.BindPFlag("log_level", rootCmd.PersistentFlags().Lookup("log-level")))
+
+	rootCmd.PersistentFlags().Bool("require-pinned", false, "Refuse to run plugin binaries without a sha256 pin")
+	Must(viper.BindPFlag("require_pinned", rootCmd.PersistentFlags().Lookup("require-pinned")))
 }
 
 // Execute runs the root command.
@@ -1,11 +1,19 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
 	"github.com/fr0stylo/sfx/config"
+	"github.com/fr0stylo/sfx/internal/client"
+	"github.com/fr0stylo/sfx/internal/rpc"
 )
 
 func init() {
@@ -16,7 +24,9 @@ func newVerifyCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "verify",
 		Short: "Verify the sfx configuration",
-		Long:  "Load the .sfx.yaml configuration and report validation issues.",
+		Long: "Load the .sfx.yaml configuration, start the plugins in use and check provider and exporter " +
+			"options against the schemas they publish. With --skip-schemas, only the configuration structure " +
+			"is checked and no plugin is started. With --deep, every secret ref is also checked for reachability.",
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			cfg, err := config.Load()
 			if err != nil {
@@ -27,10 +37,224 @@ func newVerifyCommand() *cobra.Command {
 				return fmt.Errorf("configuration invalid: %w", err)
 			}
 
+			deep, err := cmd.Flags().GetBool("deep")
+			if err != nil {
+				return err
+			}
+			if deep {
+				return verifyDeep(cmd.Context(), cmd.OutOrStdout(), cfg)
+			}
+
+			skipSchemas, err := cmd.Flags().GetBool("skip-schemas")
+			if err != nil {
+				return err
+			}
+			if skipSchemas {
+				_, err = fmt.Fprintln(cmd.OutOrStdout(), "configuration is valid; plugin options were not checked")
+				return err
+			}
+			if err := verifySchemas(cmd.Context(), cfg); err != nil {
+				return err
+			}
+
 			_, err = fmt.Fprintln(cmd.OutOrStdout(), "configuration is valid")
 			return err
 		},
 	}
 
+	cmd.Flags().Bool("skip-schemas", false, "Check the configuration structure only, without starting plugins or checking their options")
+	cmd.Flags().Bool("deep", false, "Also check that each secret ref is reachable")
+	cmd.MarkFlagsMutuallyExclusive("skip-schemas", "deep")
+
 	return cmd
 }
+
+// probeResult is the outcome of starting a plugin and reading its handshake.
+type probeResult struct {
+	entry     pluginEntry
+	spec      client.Spec
+	handshake *rpc.Handshake
+	err       error
+}
+
+type probeSet map[string]*probeResult
+
+func probeKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+func (ps probeSet) sorted() []*probeResult {
+	keys := make([]string, 0, len(ps))
+	for k := range ps {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]*probeResult, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, ps[k])
+	}
+	return out
+}
+
+func (ps probeSet) schemas() config.OptionSchemas {
+	schemas := config.OptionSchemas{
+		Providers: map[string][]byte{},
+		Exporters: map[string][]byte{},
+	}
+	for _, p := range ps {
+		if p.err != nil {
+			continue
+		}
+		switch p.entry.Kind {
+		case "provider":
+			schemas.Providers[p.entry.Name] = p.handshake.GetOptionsSchema()
+		case "exporter":
+			schemas.Exporters[p.entry.Name] = p.handshake.GetOptionsSchema()
+		}
+	}
+	return schemas
+}
+
+// probeUsedPlugins performs a handshake with every plugin the configuration uses.
+func probeUsedPlugins(ctx context.Context, cfg config.Config) probeSet {
+	probes := probeSet{}
+	for _, e := range usedPlugins(cfg) {
+		result := &probeResult{entry: e}
+		result.spec, result.handshake, result.err = probePlugin(ctx, e, cfg.RequirePinned)
+		probes[probeKey(e.Kind, e.Name)] = result
+	}
+	return probes
+}
+
+// verifySchemas checks options against the schemas of the plugins in use. A
+// plugin that cannot be started fails the check, since its options go unchecked.
+func verifySchemas(ctx context.Context, cfg config.Config) error {
+	defer func() {
+		if err := client.Shutdown(); err != nil {
+			slog.Warn("plugin shutdown", "error", err)
+		}
+	}()
+
+	probes := probeUsedPlugins(ctx, cfg)
+	var errs []error
+	for _, p := range probes.sorted() {
+		if p.err != nil {
+			errs = append(errs, fmt.Errorf("%s %q: %w", p.entry.Kind, p.entry.Name, p.err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("probe plugins: %w", errors.Join(errs...))
+	}
+
+	if err := config.ValidateOptions(cfg, probes.schemas()); err != nil {
+		return fmt.Errorf("configuration invalid: %w", err)
+	}
+	return nil
+}
+
+// verifyDeep checks plugin binaries, handshakes and ref reachability, printing a
+// pass/fail table. It returns an error when any check fails.
+func verifyDeep(ctx context.Context, out io.Writer, cfg config.Config) error {
+	defer func() {
+		if err := client.Shutdown(); err != nil {
+			slog.Warn("plugin shutdown", "error", err)
+		}
+	}()
+
+	failures := 0
+	probes := probeUsedPlugins(ctx, cfg)
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PLUGIN\tKIND\tPATH\tSTATUS\tDETAIL")
+	for _, p := range probes.sorted() {
+		status, detail := "ok", ""
+		if p.err != nil {
+			status, detail = "FAIL", p.err.Error()
+			failures++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", p.entry.Name, p.entry.Kind, valueOr(p.spec.Path, "-"), status, detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(out)
+
+	skipped := 0
+	tw = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SECRET\tPROVIDER\tREF\tSTATUS\tDETAIL")
+	for _, name := range sortedKeys(cfg.Secrets) {
+		secret := cfg.Secrets[name]
+		status, detail := "ok", ""
+
+		// Providers that do not advertise check support could only confirm a
+		// ref by fetching its value, which verify never does.
+		p := probes[probeKey("provider", secret.Provider)]
+		switch {
+		case p.err != nil:
+			status, detail = "FAIL", "provider unavailable"
+			failures++
+		case !p.handshake.GetCheck():
+			status, detail = "SKIP", "provider cannot check refs without retrieving their values"
+			skipped++
+		default:
+			if err := checkSecret(client.WithSecret(ctx, name), p.spec, secret); err != nil {
+				status, detail = "FAIL", err.Error()
+				failures++
+			}
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", name, secret.Provider, secret.Ref, status, detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if err := config.ValidateOptions(cfg, probes.schemas()); err != nil {
+		var vErr config.ValidationError
+		if errors.As(err, &vErr) {
+			failures += len(vErr.Issues)
+		} else {
+			failures++
+		}
+		fmt.Fprintf(out, "\noption errors:\n%v\n", err)
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("verification failed: %d problem(s) found", failures)
+	}
+
+	if skipped > 0 {
+		_, err := fmt.Fprintf(out, "\nconfiguration is valid; %d secret(s) were not checked\n", skipped)
+		return err
+	}
+	_, err := fmt.Fprintln(out, "\nconfiguration is valid and all secrets are reachable")
+	return err
+}
+
+// checkSecret asks the provider to confirm the secret is readable without returning its value.
+func checkSecret(ctx context.Context, spec client.Spec, secret config.Secret) error {
+	opts, err := rpc.MarshalOptions(secret.ProviderOptions)
+	if err != nil {
+		return err
+	}
+
+	req := &rpc.SecretRequest{Ref: secret.Ref, Options: opts, Check: true}
+	var resp rpc.SecretResponse
+	if err := client.CallContext(ctx, spec, req, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
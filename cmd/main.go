@@ -5,8 +5,10 @@ import (
 	"context"
 
 	"github.com/fr0stylo/sfx/cmd/cmd"
+	"github.com/fr0stylo/sfx/internal/client"
 )
 
 func main() {
+	client.SandboxMain()
 	cmd.Must(cmd.Execute(context.Background()))
 }
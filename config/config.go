@@ -4,29 +4,146 @@ package config
 import (
 	"errors"
 	"fmt"
+	"math"
+	"os"
+	"reflect"
 	"strings"
+	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds provider/exporter definitions and target output configuration.
 type Config struct {
-	Providers map[string]string `mapstructure:"providers" yaml:"providers"`
-	Exporters map[string]string `mapstructure:"exporters" yaml:"exporters"`
+	Providers map[string]Plugin `mapstructure:"providers" yaml:"providers"`
+	Exporters map[string]Plugin `mapstructure:"exporters" yaml:"exporters"`
 	Output    Output            `mapstructure:"output" yaml:"output"`
 	Secrets   map[string]Secret `mapstructure:"secrets" yaml:"secrets"`
+	// RequirePinned refuses to start plugin binaries that have no sha256 pin.
+	RequirePinned bool `mapstructure:"require_pinned" yaml:"require_pinned,omitempty"`
+	// SecretOrder lists the secret names in the order the configuration file
+	// declares them. Names are lower-cased like the keys of Secrets.
+	SecretOrder []string `mapstructure:"-" yaml:"-"`
 }
 
+// Plugin describes how a provider or exporter binary is executed.
+// In YAML it may be written either as a bare path or as a mapping.
+type Plugin struct {
+	// Path to the plugin binary; when empty the binary is looked up on the plugin search path.
+	Path string `mapstructure:"path" yaml:"path"`
+	// Mode is either "persistent" or "oneshot"; empty defers to the plugin handshake.
+	Mode string `mapstructure:"mode" yaml:"mode,omitempty"`
+	// Protocol is the wire protocol spoken by the plugin: "protobuf" (default) or "jsonl".
+	Protocol string `mapstructure:"protocol" yaml:"protocol,omitempty"`
+	// SHA256 is the expected hex digest of the binary, verified before it is executed.
+	SHA256 string `mapstructure:"sha256" yaml:"sha256,omitempty"`
+	// Env controls which environment variables the plugin process sees.
+	Env PluginEnv `mapstructure:"env" yaml:"env,omitempty"`
+	// Dir is the working directory of the plugin process; empty uses the current directory.
+	Dir string `mapstructure:"dir" yaml:"dir,omitempty"`
+	// Limits are resource limits applied to the plugin process (Linux only).
+	Limits Limits `mapstructure:"limits" yaml:"limits,omitempty"`
+	// Mounts expose host directories to WebAssembly plugins as host:guest[:ro] entries.
+	Mounts []string `mapstructure:"mounts" yaml:"mounts,omitempty"`
+}
+
+// Mount is a parsed entry of Plugin.Mounts.
+type Mount struct {
+	Host     string
+	Guest    string
+	ReadOnly bool
+}
+
+// ParseMount parses a host:guest[:ro|:rw] mount entry. The guest path must be absolute.
+func ParseMount(s string) (Mount, error) {
+	var m Mount
+	switch {
+	case strings.HasSuffix(s, ":ro"):
+		m.ReadOnly = true
+		s = strings.TrimSuffix(s, ":ro")
+	case strings.HasSuffix(s, ":rw"):
+		s = strings.TrimSuffix(s, ":rw")
+	}
+
+	i := strings.LastIndex(s, ":")
+	if i <= 0 || !strings.HasPrefix(s[i+1:], "/") {
+		return Mount{}, fmt.Errorf("mount %q must be host:guest[:ro] with an absolute guest path", s)
+	}
+	m.Host, m.Guest = s[:i], s[i+1:]
+	return m, nil
+}
+
+// PluginEnv restricts and extends the environment passed to a plugin.
+type PluginEnv struct {
+	// Allow lists glob patterns (e.g. VAULT_*) of host variables to pass through.
+	// When unset the plugin inherits the full host environment.
+	Allow []string `mapstructure:"allow" yaml:"allow,omitempty"`
+	// Set adds or overrides variables as NAME=value entries. A list is used rather
+	// than a mapping because configuration keys are case-insensitive.
+	Set []string `mapstructure:"set" yaml:"set,omitempty"`
+}
+
+// MaxAddressSpaceMB is the largest Limits.AddressSpaceMB whose size in bytes
+// fits in 64 bits.
+const MaxAddressSpaceMB = math.MaxUint64 >> 20
+
+// Limits are resource limits for a plugin process.
+type Limits struct {
+	// CPU caps the total CPU time the process may consume.
+	CPU time.Duration `mapstructure:"cpu" yaml:"cpu,omitempty"`
+	// AddressSpaceMB caps the virtual memory of the process in MiB, up to MaxAddressSpaceMB.
+	AddressSpaceMB uint64 `mapstructure:"address_space_mb" yaml:"address_space_mb,omitempty"`
+	// OpenFiles caps the number of open file descriptors.
+	OpenFiles uint64 `mapstructure:"open_files" yaml:"open_files,omitempty"`
+	// NoNewPrivs prevents the plugin from gaining privileges via setuid binaries.
+	NoNewPrivs bool `mapstructure:"no_new_privs" yaml:"no_new_privs,omitempty"`
+}
+
+// BundledProviders lists the provider plugins shipped with sfx.
+var BundledProviders = []string{"file", "vault", "sops", "awssecrets", "awsssm", "gcpsecrets", "azurevault"}
+
+// BundledExporters lists the exporter plugins shipped with sfx.
+var BundledExporters = []string{"env", "tfvars", "template", "shell", "k8ssecret", "ansible", "files", "structured"}
+
+// Plugin execution modes accepted in configuration.
+const (
+	ModePersistent = "persistent"
+	ModeOneShot    = "oneshot"
+)
+
+// Plugin wire protocols accepted in configuration.
+const (
+	ProtocolProtobuf = "protobuf"
+	ProtocolJSONL    = "jsonl"
+)
+
+// Secret value types accepted in configuration.
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeBool   = "bool"
+	TypeJSON   = "json"
+	TypeBinary = "binary"
+)
+
 // Secret identifies a provider ref and per-call options for lookup.
 type Secret struct {
 	Ref             string         `mapstructure:"ref" yaml:"ref"`
 	Provider        string         `mapstructure:"provider" yaml:"provider"`
 	ProviderOptions map[string]any `mapstructure:"provider_options" yaml:"provider_options"`
+	// Type declares how exporters should interpret the value; empty leaves it
+	// to the exporter.
+	Type string `mapstructure:"type" yaml:"type,omitempty"`
 }
 
 // Output describes how fetched secrets should be rendered.
 type Output struct {
-	Type     string         `mapstructure:"type" yaml:"type"`
+	Type string `mapstructure:"type" yaml:"type"`
+	// Dir is where files returned by the exporter are written; exporters
+	// that return files fail without it.
+	Dir      string         `mapstructure:"dir" yaml:"dir,omitempty"`
 	Template string         `mapstructure:"template" yaml:"template"`
 	Options  map[string]any `mapstructure:"options" yaml:"options"`
 }
@@ -37,19 +154,14 @@ func Load() (Config, error) {
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
 
-	viper.SetDefault("providers.file", "./bin/providers/file")
-	viper.SetDefault("providers.vault", "./bin/providers/vault")
-	viper.SetDefault("providers.sops", "./bin/providers/sops")
-	viper.SetDefault("providers.awssecrets", "./bin/providers/awssecrets")
-	viper.SetDefault("providers.awsssm", "./bin/providers/awsssm")
-	viper.SetDefault("providers.gcpsecrets", "./bin/providers/gcpsecrets")
-	viper.SetDefault("providers.azurevault", "./bin/providers/azurevault")
-	viper.SetDefault("exporters.env", "./bin/exporters/env")
-	viper.SetDefault("exporters.tfvars", "./bin/exporters/tfvars")
-	viper.SetDefault("exporters.template", "./bin/exporters/template")
-	viper.SetDefault("exporters.shell", "./bin/exporters/shell")
-	viper.SetDefault("exporters.k8ssecret", "./bin/exporters/k8ssecret")
-	viper.SetDefault("exporters.ansible", "./bin/exporters/ansible")
+	// Bundled plugins are registered without a path so they are resolved
+	// from the plugin search path at runtime.
+	for _, name := range BundledProviders {
+		viper.SetDefault("providers."+name, "")
+	}
+	for _, name := range BundledExporters {
+		viper.SetDefault("exporters."+name, "")
+	}
 	viper.SetDefault("output.type", "env")
 
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -65,9 +177,65 @@ func Load() (Config, error) {
 	}
 
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := viper.Unmarshal(&cfg, viper.DecodeHook(decodeHook())); err != nil {
 		return Config{}, fmt.Errorf("unmarshal config: %w", err)
 	}
 
+	order, err := secretOrder(viper.ConfigFileUsed())
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.SecretOrder = order
+
 	return cfg, nil
 }
+
+// secretOrder returns the names under the top-level secrets mapping of the
+// configuration file at path, in file order. Viper loses that order, so the
+// file is read again as a YAML node tree.
+func secretOrder(path string) ([]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "secrets" || root.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		secrets := root.Content[i+1]
+		names := make([]string, 0, len(secrets.Content)/2)
+		for j := 0; j+1 < len(secrets.Content); j += 2 {
+			names = append(names, strings.ToLower(secrets.Content[j].Value))
+		}
+		return names, nil
+	}
+	return nil, nil
+}
+
+func decodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		pluginPathHook(),
+	)
+}
+
+// pluginPathHook lets a plugin entry be written as a bare binary path.
+func pluginPathHook() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if to != reflect.TypeOf(Plugin{}) || from.Kind() != reflect.String {
+			return data, nil
+		}
+		return Plugin{Path: data.(string)}, nil
+	}
+}
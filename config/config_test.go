@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+func TestPluginPathHookAcceptsShorthandAndMapping(t *testing.T) {
+	raw := map[string]any{
+		"providers": map[string]any{
+			"vault": "./bin/providers/vault",
+			"file": map[string]any{
+				"path": "./bin/providers/file",
+				"mode": "oneshot",
+			},
+		},
+	}
+
+	var cfg Config
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: pluginPathHook(),
+		Result:     &cfg,
+	})
+	if err != nil {
+		t.Fatalf("new decoder: %v", err)
+	}
+	if err := dec.Decode(raw); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if got := cfg.Providers["vault"]; !reflect.DeepEqual(got, Plugin{Path: "./bin/providers/vault"}) {
+		t.Fatalf("unexpected vault plugin: %+v", got)
+	}
+	if got := cfg.Providers["file"]; !reflect.DeepEqual(got, Plugin{Path: "./bin/providers/file", Mode: ModeOneShot}) {
+		t.Fatalf("unexpected file plugin: %+v", got)
+	}
+}
+
+func TestDecodePluginIsolation(t *testing.T) {
+	raw := map[string]any{
+		"providers": map[string]any{
+			"vault": map[string]any{
+				"path": "./bin/providers/vault",
+				"dir":  "/srv/sfx",
+				"env": map[string]any{
+					"allow": []any{"VAULT_*", "HOME"},
+					"set":   []any{"VAULT_NAMESPACE=platform"},
+				},
+				"limits": map[string]any{
+					"cpu":              "30s",
+					"address_space_mb": 512,
+					"open_files":       64,
+					"no_new_privs":     true,
+				},
+			},
+		},
+	}
+
+	var cfg Config
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: decodeHook(),
+		Result:     &cfg,
+	})
+	if err != nil {
+		t.Fatalf("new decoder: %v", err)
+	}
+	if err := dec.Decode(raw); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	want := Plugin{
+		Path: "./bin/providers/vault",
+		Dir:  "/srv/sfx",
+		Env: PluginEnv{
+			Allow: []string{"VAULT_*", "HOME"},
+			Set:   []string{"VAULT_NAMESPACE=platform"},
+		},
+		Limits: Limits{CPU: 30 * time.Second, AddressSpaceMB: 512, OpenFiles: 64, NoNewPrivs: true},
+	}
+	if got := cfg.Providers["vault"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected vault plugin:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseMount(t *testing.T) {
+	cases := map[string]Mount{
+		"./secrets:/secrets:ro": {Host: "./secrets", Guest: "/secrets", ReadOnly: true},
+		"/srv/data:/data":       {Host: "/srv/data", Guest: "/data"},
+		`C:\data:/data:rw`:      {Host: `C:\data`, Guest: "/data"},
+	}
+	for in, want := range cases {
+		got, err := ParseMount(in)
+		if err != nil {
+			t.Fatalf("ParseMount(%q) returned error: %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("ParseMount(%q) = %+v, want %+v", in, got, want)
+		}
+	}
+
+	if _, err := ParseMount("./secrets:relative"); err == nil {
+		t.Fatal("expected error for relative guest path")
+	}
+}
+
+func TestSecretOrderFollowsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".sfx.yaml")
+	raw := `output:
+  type: env
+secrets:
+  ZED:
+    ref: a
+    provider: file
+  Alpha:
+    ref: b
+    provider: file
+  mid:
+    ref: c
+    provider: file
+`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	got, err := secretOrder(path)
+	if err != nil {
+		t.Fatalf("secretOrder returned error: %v", err)
+	}
+	if want := []string{"zed", "alpha", "mid"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("secretOrder = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/fr0stylo/sfx/internal/schema"
+)
+
+// OptionSchemas holds the JSON Schemas plugins published for their options, keyed by plugin name.
+// Plugins without a schema are skipped during validation.
+type OptionSchemas struct {
+	Providers map[string][]byte
+	Exporters map[string][]byte
+}
+
+// ValidateOptions checks every secret's provider_options and output.options against
+// the schemas declared by the corresponding plugins. Secrets without options are
+// still checked for required options; output options may be completed on the
+// command line and are only checked when present.
+func ValidateOptions(cfg Config, schemas OptionSchemas) error {
+	var issues []string
+
+	for _, name := range sortedKeys(cfg.Secrets) {
+		secret := cfg.Secrets[name]
+		raw, ok := schemas.Providers[secret.Provider]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+		prefix := fmt.Sprintf("secrets.%s.provider_options", name)
+		issues = append(issues, checkOptions(prefix, secret.Provider, raw, secret.ProviderOptions)...)
+	}
+
+	if raw, ok := schemas.Exporters[cfg.Output.Type]; ok && len(raw) > 0 && len(cfg.Output.Options) > 0 {
+		issues = append(issues, checkOptions("output.options", cfg.Output.Type, raw, cfg.Output.Options)...)
+	}
+
+	if len(issues) > 0 {
+		return ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+func checkOptions(prefix, plugin string, raw []byte, options map[string]any) []string {
+	s, err := schema.Parse(raw)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: plugin %q published an invalid schema: %v", prefix, plugin, err)}
+	}
+
+	var issues []string
+	for _, v := range s.Validate(options) {
+		v.Path = prefix + "." + v.Path
+		issues = append(issues, v.String())
+	}
+	return issues
+}
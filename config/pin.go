@@ -0,0 +1,106 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pin records the expected digest of a provider or exporter binary.
+type Pin struct {
+	// Kind is either "provider" or "exporter".
+	Kind   string
+	Name   string
+	SHA256 string
+}
+
+// WritePins stores pins in the configuration file at path. Plugins written as a bare
+// path are expanded to a mapping; comments and the order of existing keys are kept.
+func WritePins(path string, pins []Pin) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("parse config: top level must be a mapping")
+	}
+
+	for _, pin := range pins {
+		section := mappingValue(root, pin.Kind+"s")
+		setPluginPin(section, pin.Name, pin.SHA256)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+
+	return os.WriteFile(path, buf.Bytes(), info.Mode().Perm())
+}
+
+// mappingValue returns the mapping stored under key, creating it if needed.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	v := mappingEntry(m, key)
+	if v.Kind != yaml.MappingNode {
+		*v = yaml.Node{Kind: yaml.MappingNode}
+	}
+	return v
+}
+
+// mappingEntry returns the value node stored under key, appending a null entry if missing.
+func mappingEntry(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+
+	k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	v := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+	m.Content = append(m.Content, k, v)
+	return v
+}
+
+func setPluginPin(section *yaml.Node, name, sum string) {
+	entry := mappingEntry(section, name)
+	if entry.Kind != yaml.MappingNode {
+		path := ""
+		if entry.Kind == yaml.ScalarNode && entry.Tag != "!!null" {
+			path = entry.Value
+		}
+		// Keep a trailing comment on the shorthand line attached to the plugin name.
+		if entry.LineComment != "" {
+			for i := 0; i+1 < len(section.Content); i += 2 {
+				if section.Content[i+1] == entry {
+					section.Content[i].LineComment = entry.LineComment
+				}
+			}
+		}
+		*entry = yaml.Node{Kind: yaml.MappingNode}
+		if path != "" {
+			*mappingEntry(entry, "path") = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path}
+		}
+	}
+
+	*mappingEntry(entry, "sha256") = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: sum}
+}
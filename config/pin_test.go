@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWritePinsExpandsShorthandAndKeepsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".sfx.yaml")
+	original := `# team config
+providers:
+  vault: ./bin/providers/vault # pinned below
+  file:
+    path: ./bin/providers/file
+    mode: oneshot
+output:
+  type: env
+`
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	sum := strings.Repeat("ab", 32)
+	err := WritePins(path, []Pin{
+		{Kind: "provider", Name: "vault", SHA256: sum},
+		{Kind: "provider", Name: "file", SHA256: sum},
+		{Kind: "exporter", Name: "env", SHA256: sum},
+	})
+	if err != nil {
+		t.Fatalf("WritePins returned error: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+
+	want := `# team config
+providers:
+  vault: # pinned below
+    path: ./bin/providers/vault
+    sha256: ` + sum + `
+  file:
+    path: ./bin/providers/file
+    mode: oneshot
+    sha256: ` + sum + `
+output:
+  type: env
+exporters:
+  env:
+    sha256: ` + sum + `
+`
+	if string(raw) != want {
+		t.Fatalf("unexpected config:\n%s\nwant:\n%s", raw, want)
+	}
+}
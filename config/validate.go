@@ -1,7 +1,9 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
+	"path"
 	"sort"
 	"strings"
 )
@@ -32,9 +34,7 @@ func Validate(cfg Config) error {
 	} else {
 		providerNames := sortedKeys(cfg.Providers)
 		for _, name := range providerNames {
-			if strings.TrimSpace(cfg.Providers[name]) == "" {
-				issues = append(issues, fmt.Sprintf("provider %q has an empty binary path", name))
-			}
+			issues = append(issues, validatePlugin("provider", name, cfg.Providers[name])...)
 		}
 	}
 
@@ -43,9 +43,7 @@ func Validate(cfg Config) error {
 	} else {
 		exporterNames := sortedKeys(cfg.Exporters)
 		for _, name := range exporterNames {
-			if strings.TrimSpace(cfg.Exporters[name]) == "" {
-				issues = append(issues, fmt.Sprintf("exporter %q has an empty binary path", name))
-			}
+			issues = append(issues, validatePlugin("exporter", name, cfg.Exporters[name])...)
 		}
 	}
 
@@ -72,6 +70,11 @@ func Validate(cfg Config) error {
 			} else if _, ok := cfg.Providers[providerName]; !ok {
 				issues = append(issues, fmt.Sprintf("secret %q references unknown provider %q", name, providerName))
 			}
+			switch secret.Type {
+			case "", TypeString, TypeInt, TypeBool, TypeJSON, TypeBinary:
+			default:
+				issues = append(issues, fmt.Sprintf("secret %q has unknown type %q (want %s, %s, %s, %s or %s)", name, secret.Type, TypeString, TypeInt, TypeBool, TypeJSON, TypeBinary))
+			}
 		}
 	}
 
@@ -81,6 +84,53 @@ func Validate(cfg Config) error {
 	return nil
 }
 
+func validatePlugin(kind, name string, p Plugin) []string {
+	var issues []string
+	switch p.Mode {
+	case "", ModePersistent, ModeOneShot:
+	default:
+		issues = append(issues, fmt.Sprintf("%s %q has unknown mode %q (want %q or %q)", kind, name, p.Mode, ModePersistent, ModeOneShot))
+	}
+	switch p.Protocol {
+	case "", ProtocolProtobuf, ProtocolJSONL:
+	default:
+		issues = append(issues, fmt.Sprintf("%s %q has unknown protocol %q (want %q or %q)", kind, name, p.Protocol, ProtocolProtobuf, ProtocolJSONL))
+	}
+	if p.SHA256 != "" && !isSHA256(p.SHA256) {
+		issues = append(issues, fmt.Sprintf("%s %q has invalid sha256 %q (want 64 hex characters)", kind, name, p.SHA256))
+	}
+	for _, pattern := range p.Env.Allow {
+		if _, err := path.Match(pattern, ""); err != nil {
+			issues = append(issues, fmt.Sprintf("%s %q has invalid env.allow pattern %q", kind, name, pattern))
+		}
+	}
+	for _, kv := range p.Env.Set {
+		if key, _, ok := strings.Cut(kv, "="); !ok || key == "" {
+			issues = append(issues, fmt.Sprintf("%s %q has invalid env.set entry %q (want NAME=value)", kind, name, kv))
+		}
+	}
+	for _, mount := range p.Mounts {
+		if _, err := ParseMount(mount); err != nil {
+			issues = append(issues, fmt.Sprintf("%s %q: %v", kind, name, err))
+		}
+	}
+	if p.Limits.CPU < 0 {
+		issues = append(issues, fmt.Sprintf("%s %q has negative limits.cpu %s", kind, name, p.Limits.CPU))
+	}
+	if p.Limits.AddressSpaceMB > MaxAddressSpaceMB {
+		issues = append(issues, fmt.Sprintf("%s %q has limits.address_space_mb %d above the maximum of %d", kind, name, p.Limits.AddressSpaceMB, uint64(MaxAddressSpaceMB)))
+	}
+	return issues
+}
+
+func isSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
 func sortedKeys[T any](m map[string]T) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
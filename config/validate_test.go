@@ -8,11 +8,11 @@ import (
 
 func TestValidateSuccess(t *testing.T) {
 	cfg := Config{
-		Providers: map[string]string{
-			"vault": "./bin/providers/vault",
+		Providers: map[string]Plugin{
+			"vault": {Path: "./bin/providers/vault"},
 		},
-		Exporters: map[string]string{
-			"env": "./bin/exporters/env",
+		Exporters: map[string]Plugin{
+			"env": {Path: "./bin/exporters/env", Mode: ModeOneShot},
 		},
 		Output: Output{
 			Type: "env",
@@ -32,10 +32,10 @@ func TestValidateSuccess(t *testing.T) {
 
 func TestValidateReportsIssues(t *testing.T) {
 	cfg := Config{
-		Providers: map[string]string{
-			"vault": "",
+		Providers: map[string]Plugin{
+			"vault": {Path: "", Mode: "forever", Protocol: "xml", SHA256: "abc", Env: PluginEnv{Allow: []string{"VAULT_["}, Set: []string{"NOVALUE"}}, Mounts: []string{"./data"}, Limits: Limits{AddressSpaceMB: 1 << 44}},
 		},
-		Exporters: map[string]string{},
+		Exporters: map[string]Plugin{},
 		Output: Output{
 			Type: "shell",
 		},
@@ -43,6 +43,7 @@ func TestValidateReportsIssues(t *testing.T) {
 			"": {
 				Ref:      "",
 				Provider: "aws",
+				Type:     "float",
 			},
 		},
 	}
@@ -62,12 +63,19 @@ func TestValidateReportsIssues(t *testing.T) {
 	}
 
 	wantSubstrings := []string{
-		"provider \"vault\" has an empty binary path",
+		"provider \"vault\" has unknown mode \"forever\"",
+		"provider \"vault\" has unknown protocol \"xml\"",
+		"provider \"vault\" has invalid sha256 \"abc\"",
+		"provider \"vault\" has invalid env.allow pattern \"VAULT_[\"",
+		"provider \"vault\" has invalid env.set entry \"NOVALUE\"",
+		"provider \"vault\": mount \"./data\" must be host:guest[:ro]",
+		"provider \"vault\" has limits.address_space_mb 17592186044416 above the maximum",
 		"no exporters configured",
 		"output.type \"shell\" does not match any configured exporter",
 		"secret name cannot be empty",
 		"secret \"\" is missing ref",
 		"secret \"\" references unknown provider \"aws\"",
+		"secret \"\" has unknown type \"float\"",
 	}
 
 	for _, want := range wantSubstrings {
@@ -83,3 +91,68 @@ func TestValidateReportsIssues(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateOptionsReportsPaths(t *testing.T) {
+	schema := []byte(`{"type":"object","properties":{"address":{"type":"string"},"timeout":{"type":"string","format":"duration"},"tags":{"type":"array","items":{"type":"string"}}},"additionalProperties":false}`)
+
+	cfg := Config{
+		Output: Output{
+			Type:    "env",
+			Options: map[string]any{"key_template": 42},
+		},
+		Secrets: map[string]Secret{
+			"DB_PASSWORD": {
+				Ref:      "secret/data/app#password",
+				Provider: "vault",
+				ProviderOptions: map[string]any{
+					"adress":  "https://vault.example.com",
+					"timeout": "soon",
+					"tags":    []any{"ok", map[string]any{"x": 1}},
+				},
+			},
+		},
+	}
+
+	err := ValidateOptions(cfg, OptionSchemas{
+		Providers: map[string][]byte{"vault": schema},
+		Exporters: map[string][]byte{"env": []byte(`{"type":"object","properties":{"key_template":{"type":"boolean"}}}`)},
+	})
+
+	var vErr ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	want := []string{
+		`secrets.DB_PASSWORD.provider_options.adress: unknown option "adress" (accepted: address, tags, timeout)`,
+		`secrets.DB_PASSWORD.provider_options.tags[1]: expected a string, got a mapping`,
+		`secrets.DB_PASSWORD.provider_options.timeout: invalid duration "soon" (use values like 30s or 5m)`,
+		`output.options.key_template: expected a boolean, got number 42`,
+	}
+	if strings.Join(vErr.Issues, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected issues:\n%s", strings.Join(vErr.Issues, "\n"))
+	}
+}
+
+func TestValidateOptionsReportsMissingRequiredOptions(t *testing.T) {
+	schema := []byte(`{"type":"object","properties":{"path":{"type":"string"}},"required":["path"],"additionalProperties":false}`)
+
+	cfg := Config{
+		Output: Output{Type: "env"},
+		Secrets: map[string]Secret{
+			"API_KEY": {Ref: "env://API_KEY", Provider: "file"},
+			"DB_PASS": {Ref: "env://DB_PASS", Provider: "file", ProviderOptions: map[string]any{"path": ".env"}},
+		},
+	}
+
+	err := ValidateOptions(cfg, OptionSchemas{Providers: map[string][]byte{"file": schema}})
+
+	var vErr ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	want := "secrets.API_KEY.provider_options.path: required option is not set"
+	if len(vErr.Issues) != 1 || vErr.Issues[0] != want {
+		t.Fatalf("unexpected issues: %v", vErr.Issues)
+	}
+}
@@ -0,0 +1,41 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fr0stylo/sfx/internal/builtin"
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// Register links h into the sfx binary as the builtin exporter name, addressed
+// as builtin:<name> in configuration. Requests are dispatched in-process with the
+// same semantics as Run. It is meant to be called from init and panics if the
+// name is already taken or the options cannot be described.
+func Register(name string, h Handler, opts ...Option) {
+	hs, err := newSettings(opts).handshake()
+	if err != nil {
+		panic(fmt.Sprintf("exporter: register %q: %v", name, err))
+	}
+	hs.ProtocolVersion = rpc.ProtocolVersion
+
+	builtin.Register(hs.GetKind(), name, builtin.Plugin{
+		Handshake: hs,
+		Call: func(_ context.Context, req proto.Message, resp proto.Message) error {
+			in, ok := req.(*rpc.ExportRequest)
+			if !ok {
+				return fmt.Errorf("exporter: unexpected request type %T", req)
+			}
+
+			out, err := dispatch(h, in)
+			if err != nil {
+				out = &rpc.ExportResponse{Error: err.Error()}
+			}
+			proto.Reset(resp)
+			proto.Merge(resp, out)
+			return nil
+		},
+	})
+}
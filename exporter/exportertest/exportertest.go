@@ -0,0 +1,209 @@
+// Package exportertest runs exporter handlers over in-memory pipes so plugin
+// tests exercise the same framing, option encoding and error reporting as sfx.
+package exportertest
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// Error is an error reported by the handler in a response, as opposed to a
+// failure of the transport.
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Plugin is an exporter handler served over in-memory pipes.
+type Plugin struct {
+	t         testing.TB
+	codec     rpc.Codec
+	in        *io.PipeWriter
+	out       *bufio.Reader
+	handshake *rpc.Handshake
+
+	mu sync.Mutex
+}
+
+// Start serves h with the default protobuf framing and reads its handshake. The
+// plugin is shut down when the test ends.
+func Start(t testing.TB, h exporter.Handler, opts ...exporter.Option) *Plugin {
+	t.Helper()
+	return StartProtocol(t, rpc.ProtocolProtobuf, h, opts...)
+}
+
+// StartProtocol is like Start but uses the named wire protocol ("protobuf" or "jsonl").
+func StartProtocol(t testing.TB, protocol string, h exporter.Handler, opts ...exporter.Option) *Plugin {
+	t.Helper()
+
+	codec, err := rpc.CodecFor(protocol)
+	if err != nil {
+		t.Fatalf("exportertest: %v", err)
+	}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := exporter.ServeStream(protocol, inR, outW, h, opts...)
+		_ = outW.CloseWithError(io.EOF)
+		done <- err
+	}()
+
+	p := &Plugin{t: t, codec: codec, in: inW, out: bufio.NewReader(outR)}
+	t.Cleanup(func() {
+		_ = inW.Close()
+		_ = outR.Close()
+		if err := <-done; err != nil {
+			t.Errorf("exportertest: serve: %v", err)
+		}
+	})
+
+	hs, err := rpc.ReadHandshake(codec, p.out)
+	if err != nil {
+		t.Fatalf("exportertest: %v", err)
+	}
+	if hs.GetKind() != "exporter" {
+		t.Fatalf("exportertest: handshake kind %q, want exporter", hs.GetKind())
+	}
+	p.handshake = hs
+	return p
+}
+
+// Mode returns the execution mode advertised in the handshake.
+func (p *Plugin) Mode() string {
+	return p.handshake.GetMode()
+}
+
+// OptionsSchema returns the JSON Schema advertised for the plugin's options.
+func (p *Plugin) OptionsSchema() []byte {
+	return p.handshake.GetOptionsSchema()
+}
+
+// Export renders values with options encoded as sfx encodes output options.
+// Errors reported by the handler are returned as *Error; transport failures
+// fail the test.
+func (p *Plugin) Export(values map[string]string, options map[string]any) ([]byte, error) {
+	p.t.Helper()
+	return p.ExportMetadata(values, nil, options)
+}
+
+// ExportMetadata is like Export but also sends metadata for the secrets, keyed
+// by secret name as sfx sends what providers reported.
+func (p *Plugin) ExportMetadata(values map[string]string, metadata map[string]map[string]string, options map[string]any) ([]byte, error) {
+	p.t.Helper()
+
+	raw := make(map[string][]byte, len(values))
+	for k, v := range values {
+		raw[k] = []byte(v)
+	}
+	resp := p.call(&rpc.ExportRequest{Values: raw, Options: p.options(options), Metadata: rpc.WrapMetadata(metadata)})
+	if resp.GetError() != "" {
+		return nil, &Error{Message: resp.GetError()}
+	}
+	return resp.GetPayload(), nil
+}
+
+// ExportRequest sends req as sfx would, including its Keys, Metadata and
+// Types, and returns the whole response, files included. req.Options must
+// already be encoded; see MarshalOptions.
+func (p *Plugin) ExportRequest(req exporter.Request) (exporter.Response, error) {
+	p.t.Helper()
+	resp := p.call(&rpc.ExportRequest{
+		Values:   req.Values,
+		Options:  req.Options,
+		Keys:     req.Keys,
+		Metadata: rpc.WrapMetadata(req.Metadata),
+		Types:    req.Types,
+	})
+	if resp.GetError() != "" {
+		return exporter.Response{}, &Error{Message: resp.GetError()}
+	}
+
+	out := exporter.Response{Payload: resp.GetPayload(), Prune: resp.GetPrune()}
+	for _, f := range resp.GetFiles() {
+		out.Files = append(out.Files, exporter.File{Path: f.GetPath(), Content: f.GetContent(), Mode: fs.FileMode(f.GetMode())})
+	}
+	return out, nil
+}
+
+// Files renders values with options and returns the files the exporter
+// produced.
+func (p *Plugin) Files(values map[string]string, options map[string]any) ([]exporter.File, error) {
+	p.t.Helper()
+
+	raw := make(map[string][]byte, len(values))
+	for k, v := range values {
+		raw[k] = []byte(v)
+	}
+	resp, err := p.ExportRequest(exporter.Request{Values: raw, Options: p.options(options)})
+	return resp.Files, err
+}
+
+// ExpectPayload fails the test unless values render to want.
+func (p *Plugin) ExpectPayload(values map[string]string, options map[string]any, want string) {
+	p.t.Helper()
+	got, err := p.Export(values, options)
+	if err != nil {
+		p.t.Errorf("Export returned error: %v", err)
+		return
+	}
+	if string(got) != want {
+		p.t.Errorf("unexpected payload:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+// ExpectError fails the test unless the handler reports an error whose message
+// contains substr.
+func (p *Plugin) ExpectError(values map[string]string, options map[string]any, substr string) {
+	p.t.Helper()
+	_, err := p.Export(values, options)
+	var perr *Error
+	if !errors.As(err, &perr) {
+		p.t.Errorf("Export succeeded, want error containing %q", substr)
+		return
+	}
+	if !strings.Contains(perr.Message, substr) {
+		p.t.Errorf("Export error %q does not contain %q", perr.Message, substr)
+	}
+}
+
+func (p *Plugin) options(options map[string]any) []byte {
+	p.t.Helper()
+	raw, err := rpc.MarshalOptions(options)
+	if err != nil {
+		p.t.Fatalf("exportertest: marshal options: %v", err)
+	}
+	return raw
+}
+
+func (p *Plugin) call(req *rpc.ExportRequest) *rpc.ExportResponse {
+	p.t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.codec.WriteMessage(p.in, req); err != nil {
+		p.t.Fatalf("exportertest: send request: %v", err)
+	}
+	resp := &rpc.ExportResponse{}
+	if err := p.codec.ReadMessage(p.out, resp); err != nil {
+		p.t.Fatalf("exportertest: read response: %v", err)
+	}
+	return resp
+}
+
+// MarshalOptions encodes options the way sfx sends output options to exporters.
+func MarshalOptions(options map[string]any) ([]byte, error) {
+	return rpc.MarshalOptions(options)
+}
@@ -0,0 +1,103 @@
+package exportertest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+type lineOptions struct {
+	Separator string `yaml:"separator"`
+}
+
+var lines = exporter.HandlerFunc(func(req exporter.Request) (exporter.Response, error) {
+	opts := lineOptions{Separator: "="}
+	if err := yaml.Unmarshal(req.Options, &opts); err != nil {
+		return exporter.Response{}, err
+	}
+
+	keys := make([]string, 0, len(req.Values))
+	for k := range req.Values {
+		if k == "" {
+			return exporter.Response{}, fmt.Errorf("empty key")
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s%s%s\n", k, opts.Separator, req.Values[k])
+	}
+	return exporter.Response{Payload: []byte(b.String())}, nil
+})
+
+var versions = exporter.HandlerFunc(func(req exporter.Request) (exporter.Response, error) {
+	keys := make([]string, 0, len(req.Values))
+	for k := range req.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s@%s%s\n", k, req.Metadata[k][exporter.MetaVersion], req.Types[k])
+	}
+	return exporter.Response{Payload: []byte(b.String())}, nil
+})
+
+var tree = exporter.HandlerFunc(func(req exporter.Request) (exporter.Response, error) {
+	var resp exporter.Response
+	for _, k := range []string{"A", "B"} {
+		resp.Files = append(resp.Files, exporter.File{Path: k, Content: req.Values[k], Mode: 0o640})
+	}
+	return resp, nil
+})
+
+func TestPluginRoundTrip(t *testing.T) {
+	for _, protocol := range []string{rpc.ProtocolProtobuf, rpc.ProtocolJSONL} {
+		t.Run(protocol, func(t *testing.T) {
+			p := StartProtocol(t, protocol, lines)
+
+			values := map[string]string{"B": "2", "A": "1"}
+			p.ExpectPayload(values, nil, "A=1\nB=2\n")
+			p.ExpectPayload(values, map[string]any{"separator": ": "}, "A: 1\nB: 2\n")
+			p.ExpectPayload(nil, nil, "")
+
+			got, err := StartProtocol(t, protocol, versions).ExportMetadata(values, map[string]map[string]string{
+				"A": {exporter.MetaVersion: "3"},
+			}, nil)
+			if err != nil || string(got) != "A@3\nB@\n" {
+				t.Fatalf("ExportMetadata = (%q, %v), want versions", got, err)
+			}
+
+			resp, err := StartProtocol(t, protocol, versions).ExportRequest(exporter.Request{
+				Values: map[string][]byte{"A": []byte("1")},
+				Types:  map[string]string{"A": exporter.TypeInt},
+			})
+			if err != nil || string(resp.Payload) != "A@int\n" {
+				t.Fatalf("ExportRequest = (%q, %v), want types", resp.Payload, err)
+			}
+
+			files, err := StartProtocol(t, protocol, tree).Files(values, nil)
+			want := []exporter.File{{Path: "A", Content: []byte("1"), Mode: 0o640}, {Path: "B", Content: []byte("2"), Mode: 0o640}}
+			if err != nil || !reflect.DeepEqual(files, want) {
+				t.Fatalf("Files = (%v, %v), want %v", files, err, want)
+			}
+
+			_, err = p.Export(map[string]string{"": "x"}, nil)
+			var perr *Error
+			if !errors.As(err, &perr) || perr.Message != "empty key" {
+				t.Fatalf("Export = %v, want *Error(empty key)", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package keys
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Funcs returns the functions available to key templates: upper, lower, trim,
+// trimPrefix, trimSuffix and replace, with the argument order of the
+// sprig functions of the same names, plus one function per key case (snake,
+// screaming_snake, kebab, camel and pascal).
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"upper":           strings.ToUpper,
+		"lower":           strings.ToLower,
+		"trim":            strings.TrimSpace,
+		"trimPrefix":      func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix":      func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":         func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"snake":           snake,
+		"screaming_snake": func(s string) string { return strings.ToUpper(snake(s)) },
+		"kebab":           kebab,
+		"camel":           camel,
+		"pascal":          pascal,
+	}
+}
+
+// Case returns the conversion for a key_case value, or the identity for "",
+// for exporters that apply the case to parts of a key themselves.
+func Case(name string) (func(string) string, error) {
+	switch name {
+	case "":
+		return func(s string) string { return s }, nil
+	case "upper":
+		return strings.ToUpper, nil
+	case "lower":
+		return strings.ToLower, nil
+	case "snake":
+		return snake, nil
+	case "screaming_snake":
+		return func(s string) string { return strings.ToUpper(snake(s)) }, nil
+	case "kebab":
+		return kebab, nil
+	case "camel":
+		return camel, nil
+	case "pascal":
+		return pascal, nil
+	default:
+		return nil, fmt.Errorf("unknown key_case %q (use upper, lower, snake, screaming_snake, kebab, camel or pascal)", name)
+	}
+}
+
+func snake(s string) string {
+	return strings.ToLower(strings.Join(words(s), "_"))
+}
+
+func kebab(s string) string {
+	return strings.ToLower(strings.Join(words(s), "-"))
+}
+
+func camel(s string) string {
+	p := pascal(s)
+	for i, r := range p {
+		return string(unicode.ToLower(r)) + p[i+len(string(r)):]
+	}
+	return p
+}
+
+func pascal(s string) string {
+	var b strings.Builder
+	for _, w := range words(s) {
+		w = strings.ToLower(w)
+		for i, r := range w {
+			b.WriteRune(unicode.ToUpper(r))
+			b.WriteString(w[i+len(string(r)):])
+			break
+		}
+	}
+	return b.String()
+}
+
+// words splits s at separators (anything but letters and digits) and at case
+// changes, so "dbPassword", "DB_PASSWORD", "db-password" and "HTTPServer2"
+// split into words the same way.
+func words(s string) []string {
+	var (
+		out  []string
+		cur  []rune
+		prev rune
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			out = append(out, string(cur))
+			cur = cur[:0]
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			prev = 0
+			continue
+		}
+		if len(cur) > 0 && unicode.IsUpper(r) {
+			// "dbPassword" splits before P; "HTTPServer" splits before S.
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		cur = append(cur, r)
+		prev = r
+	}
+	flush()
+	return out
+}
@@ -0,0 +1,48 @@
+package keys
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Format describes the keys a target format accepts.
+type Format struct {
+	// Name describes the key in errors, e.g. "environment variable name".
+	Name string
+	// Pattern, when set, must match every key.
+	Pattern *regexp.Regexp
+	// MaxLen, when positive, limits the key length in bytes.
+	MaxLen int
+}
+
+var (
+	// Any accepts every non-empty key.
+	Any = Format{Name: "key"}
+	// EnvVar accepts POSIX environment variable names.
+	EnvVar = Format{Name: "environment variable name", Pattern: regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)}
+	// DotenvKey accepts the keys dotenv loaders understand, which unlike shell
+	// variables may contain dots and dashes.
+	DotenvKey = Format{Name: "dotenv key", Pattern: regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)}
+	// HCLIdentifier accepts Terraform variable names.
+	HCLIdentifier = Format{Name: "Terraform variable name", Pattern: regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)}
+	// AnsibleVar accepts Ansible variable names.
+	AnsibleVar = Format{Name: "Ansible variable name", Pattern: regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)}
+	// K8sDataKey accepts keys of a Kubernetes Secret's data.
+	K8sDataKey = Format{Name: "Kubernetes secret key", Pattern: regexp.MustCompile(`^[-._a-zA-Z0-9]+$`), MaxLen: 253}
+	// FilePath accepts relative, slash-separated file paths whose segments are
+	// portable file names that do not start with a dot.
+	FilePath = Format{Name: "file path", Pattern: regexp.MustCompile(`^[-_a-zA-Z0-9][-._a-zA-Z0-9]*(/[-_a-zA-Z0-9][-._a-zA-Z0-9]*)*$`), MaxLen: 255}
+)
+
+// Check reports whether key is valid in f.
+func (f Format) Check(key string) error {
+	switch {
+	case key == "":
+		return fmt.Errorf("empty %s", f.Name)
+	case f.MaxLen > 0 && len(key) > f.MaxLen:
+		return fmt.Errorf("%q is not a valid %s (longer than %d characters)", key, f.Name, f.MaxLen)
+	case f.Pattern != nil && !f.Pattern.MatchString(key):
+		return fmt.Errorf("%q is not a valid %s (must match %s)", key, f.Name, f.Pattern)
+	}
+	return nil
+}
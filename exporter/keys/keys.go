@@ -0,0 +1,252 @@
+// Package keys orders, renames and validates the names under which an exporter
+// writes secrets, so that every exporter accepts the same key options.
+package keys
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fr0stylo/sfx/exporter"
+)
+
+// Sort values accepted by Options.Sort.
+const (
+	// SortName orders keys alphabetically by secret name.
+	SortName = "name"
+	// SortConfig orders keys as the secrets are declared in the configuration.
+	SortConfig = "config"
+)
+
+// Options are the key options shared by the bundled exporters. Embed them
+// inline in an exporter's options struct:
+//
+//	type options struct {
+//		keys.Options `yaml:",inline"`
+//		Shebang      string `yaml:"shebang"`
+//	}
+//
+// Order and Rename refer to secrets by their name in the configuration.
+type Options struct {
+	Order       []string          `yaml:"order" desc:"Secrets to emit first, in order"`
+	Sort        string            `yaml:"sort" default:"name" desc:"Order of the remaining secrets: name or config (as declared in .sfx.yaml)"`
+	KeyTemplate string            `yaml:"key_template" desc:"Go template applied to each secret name ({{ .Key }})"`
+	KeyCase     string            `yaml:"key_case" desc:"Case applied to each key: upper, lower, snake, screaming_snake, kebab, camel or pascal"`
+	Prefix      string            `yaml:"prefix" desc:"Prefix added to every key"`
+	Suffix      string            `yaml:"suffix" desc:"Suffix added to every key"`
+	Rename      map[string]string `yaml:"rename" desc:"Exact keys for individual secrets, used instead of the other key options"`
+
+	// Funcs extends the functions available to KeyTemplate.
+	Funcs template.FuncMap `yaml:"-"`
+}
+
+// Key is a secret together with the name it is exported under.
+type Key struct {
+	// Name is the exported key.
+	Name string
+	// Secret is the secret's name in the configuration.
+	Secret string
+	Value  []byte
+	// Metadata is what the host reported about the secret, if anything.
+	Metadata map[string]string
+	// Type is the declared type of the value, or "" when none was declared.
+	Type string
+}
+
+// Parsed returns the value converted to its declared type; see
+// exporter.ParseValue.
+func (k Key) Parsed() (any, error) {
+	v, err := exporter.ParseValue(k.Type, k.Value)
+	if err != nil {
+		return nil, fmt.Errorf("secret %q: %w", k.Secret, err)
+	}
+	return v, nil
+}
+
+// Apply orders the values of req, derives the exported name of each secret and
+// checks the names against format. Two secrets exported under the same name
+// are an error.
+func (o Options) Apply(req exporter.Request, format Format) ([]Key, error) {
+	secrets, err := o.order(req)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := o.namer()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Key, 0, len(secrets))
+	owner := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		key, err := name(secret)
+		if err != nil {
+			return nil, err
+		}
+		if err := format.Check(key); err != nil {
+			return nil, fmt.Errorf("secret %q: %w", secret, err)
+		}
+		if prev, ok := owner[key]; ok {
+			return nil, fmt.Errorf("secrets %q and %q are both exported as %q", prev, secret, key)
+		}
+		owner[key] = secret
+		out = append(out, Key{
+			Name:     key,
+			Secret:   secret,
+			Value:    req.Values[secret],
+			Metadata: req.Metadata[secret],
+			Type:     req.Types[secret],
+		})
+	}
+	return out, nil
+}
+
+// order returns the secret names of req: those listed in Order first, then the
+// rest as selected by Sort.
+func (o Options) order(req exporter.Request) ([]string, error) {
+	lookup := newLookup(req.Values)
+	seen := make(map[string]bool, len(req.Values))
+	out := make([]string, 0, len(req.Values))
+
+	add := func(names []string) {
+		for _, n := range names {
+			if secret, ok := lookup.find(n); ok && !seen[secret] {
+				seen[secret] = true
+				out = append(out, secret)
+			}
+		}
+	}
+
+	add(o.Order)
+	switch o.Sort {
+	case "", SortName:
+	case SortConfig:
+		add(req.Keys)
+	default:
+		return nil, fmt.Errorf("unknown sort %q (use %s or %s)", o.Sort, SortName, SortConfig)
+	}
+
+	rest := make([]string, 0, len(req.Values)-len(out))
+	for secret := range req.Values {
+		if !seen[secret] {
+			rest = append(rest, secret)
+		}
+	}
+	sort.Strings(rest)
+	return append(out, rest...), nil
+}
+
+// namer compiles the renaming options into a function from secret name to key.
+func (o Options) namer() (func(string) (string, error), error) {
+	var tmpl *template.Template
+	if o.KeyTemplate != "" {
+		funcs := Funcs()
+		for name, fn := range o.Funcs {
+			funcs[name] = fn
+		}
+		var err error
+		if tmpl, err = template.New("key").Funcs(funcs).Parse(o.KeyTemplate); err != nil {
+			return nil, fmt.Errorf("parse key template: %w", err)
+		}
+	}
+
+	convert, err := Case(o.KeyCase)
+	if err != nil {
+		return nil, err
+	}
+
+	rename := newLookup(o.Rename)
+	return func(secret string) (string, error) {
+		if name, ok := rename.find(secret); ok {
+			return o.Rename[name], nil
+		}
+
+		key := secret
+		if tmpl != nil {
+			var b bytes.Buffer
+			// Value is the name the env exporter's templates have always used.
+			if err := tmpl.Execute(&b, struct{ Key, Value string }{secret, secret}); err != nil {
+				return "", fmt.Errorf("secret %q: execute key template: %w", secret, err)
+			}
+			key = b.String()
+		}
+		return o.Prefix + convert(key) + o.Suffix, nil
+	}, nil
+}
+
+// lookup finds map keys by exact name, falling back to a case-insensitive
+// match: the configuration loader lower-cases secret names, while users write
+// them in Order and Rename as they appear in the file.
+type lookup struct {
+	exact map[string]bool
+	fold  map[string]string
+}
+
+func newLookup[V any](m map[string]V) lookup {
+	l := lookup{exact: make(map[string]bool, len(m)), fold: make(map[string]string, len(m))}
+	for k := range m {
+		l.exact[k] = true
+		l.fold[strings.ToLower(k)] = k
+	}
+	return l
+}
+
+func (l lookup) find(name string) (string, bool) {
+	if l.exact[name] {
+		return name, true
+	}
+	k, ok := l.fold[strings.ToLower(name)]
+	return k, ok
+}
+
+// Mapping returns a YAML mapping of the keys to their values as strings, in
+// the order given; yaml.Marshal of a Go map would sort them instead. See
+// TypedMapping for output that honours declared types.
+func Mapping(ks []Key) *yaml.Node {
+	m := &yaml.Node{Kind: yaml.MappingNode}
+	for _, k := range ks {
+		m.Content = append(m.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k.Name},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: string(k.Value)},
+		)
+	}
+	return m
+}
+
+// TypedMapping is like Mapping, but values with a declared type are written as
+// YAML of that type: integers, booleans, the JSON document as YAML, and binary
+// values as !!binary. Values without a declared type stay strings.
+func TypedMapping(ks []Key) (*yaml.Node, error) {
+	m := &yaml.Node{Kind: yaml.MappingNode}
+	for _, k := range ks {
+		value, err := TypedNode(k)
+		if err != nil {
+			return nil, err
+		}
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k.Name}, value)
+	}
+	return m, nil
+}
+
+// TypedNode returns the value of k as a YAML node of its declared type, as
+// TypedMapping writes it.
+func TypedNode(k Key) (*yaml.Node, error) {
+	v, err := k.Parsed()
+	if err != nil {
+		return nil, err
+	}
+	if k.Type == exporter.TypeBinary {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!binary", Value: base64.StdEncoding.EncodeToString(k.Value)}, nil
+	}
+	value := &yaml.Node{}
+	if err := value.Encode(v); err != nil {
+		return nil, fmt.Errorf("secret %q: encode value: %w", k.Secret, err)
+	}
+	return value, nil
+}
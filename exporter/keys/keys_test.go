@@ -0,0 +1,134 @@
+package keys
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fr0stylo/sfx/exporter"
+)
+
+var request = exporter.Request{
+	Values: map[string][]byte{
+		"db_password": []byte("p"),
+		"api_key":     []byte("k"),
+		"zone":        []byte("z"),
+	},
+	Keys: []string{"zone", "db_password", "api_key"},
+}
+
+func names(t *testing.T, o Options, format Format) []string {
+	t.Helper()
+	keys, err := o.Apply(request, format)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = k.Name
+	}
+	return out
+}
+
+func TestApplyOrders(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{name: "sorted by default", want: []string{"api_key", "db_password", "zone"}},
+		{name: "config order", opts: Options{Sort: SortConfig}, want: []string{"zone", "db_password", "api_key"}},
+		{name: "explicit first", opts: Options{Order: []string{"ZONE", "missing"}}, want: []string{"zone", "api_key", "db_password"}},
+		{name: "explicit then config", opts: Options{Order: []string{"api_key"}, Sort: SortConfig}, want: []string{"api_key", "zone", "db_password"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(t, tt.opts, Any); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyRenames(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{name: "template", opts: Options{KeyTemplate: "{{ .Key | upper }}"}, want: []string{"API_KEY", "DB_PASSWORD", "ZONE"}},
+		{name: "legacy template value", opts: Options{KeyTemplate: `{{ .Value | replace "_" "." }}`}, want: []string{"api.key", "db.password", "zone"}},
+		{name: "case and affixes", opts: Options{KeyCase: "camel", Prefix: "app_", Suffix: "_v1"}, want: []string{"app_apiKey_v1", "app_dbPassword_v1", "app_zone_v1"}},
+		{name: "rename wins", opts: Options{KeyCase: "upper", Rename: map[string]string{"DB_PASSWORD": "PGPASSWORD"}}, want: []string{"API_KEY", "PGPASSWORD", "ZONE"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(t, tt.opts, Any); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("names = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyRejectsInvalidAndDuplicateKeys(t *testing.T) {
+	_, err := Options{KeyCase: "kebab"}.Apply(request, EnvVar)
+	if err == nil || !strings.Contains(err.Error(), `secret "api_key": "api-key" is not a valid environment variable name`) {
+		t.Fatalf("Apply error = %v, want invalid env var name", err)
+	}
+
+	_, err = Options{Rename: map[string]string{"zone": "API_KEY"}, KeyCase: "upper"}.Apply(request, EnvVar)
+	if err == nil || !strings.Contains(err.Error(), `both exported as "API_KEY"`) {
+		t.Fatalf("Apply error = %v, want duplicate key", err)
+	}
+
+	if _, err := (Options{KeyTemplate: `{{ .Key | replace "_" "/../" }}`}).Apply(request, FilePath); err == nil {
+		t.Fatal("Apply accepted a file path that leaves its directory")
+	}
+
+	if _, err := (Options{Sort: "random"}).Apply(request, Any); err == nil {
+		t.Fatal("Apply accepted an unknown sort")
+	}
+}
+
+func TestCases(t *testing.T) {
+	for in, want := range map[string][4]string{
+		"db_password": {"db_password", "db-password", "dbPassword", "DbPassword"},
+		"HTTPServer2": {"http_server2", "http-server2", "httpServer2", "HttpServer2"},
+		"dbPassword":  {"db_password", "db-password", "dbPassword", "DbPassword"},
+	} {
+		got := [4]string{snake(in), kebab(in), camel(in), pascal(in)}
+		if got != want {
+			t.Errorf("cases of %q = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestTypedMapping(t *testing.T) {
+	node, err := TypedMapping([]Key{
+		{Name: "zip", Value: []byte("01234")},
+		{Name: "port", Type: exporter.TypeInt, Value: []byte("8080")},
+		{Name: "debug", Type: exporter.TypeBool, Value: []byte("true")},
+		{Name: "cfg", Type: exporter.TypeJSON, Value: []byte(`{"hosts":["a"]}`)},
+		{Name: "blob", Type: exporter.TypeBinary, Value: []byte{0, 1}},
+	})
+	if err != nil {
+		t.Fatalf("TypedMapping returned error: %v", err)
+	}
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	want := "zip: \"01234\"\nport: 8080\ndebug: true\ncfg:\n    hosts:\n        - a\nblob: !!binary AAE=\n"
+	if string(out) != want {
+		t.Fatalf("TypedMapping =\n%s\nwant\n%s", out, want)
+	}
+
+	if _, err := TypedMapping([]Key{{Name: "port", Secret: "port", Type: exporter.TypeInt, Value: []byte("x")}}); err == nil || !strings.Contains(err.Error(), `secret "port"`) {
+		t.Fatalf("TypedMapping error = %v, want secret named", err)
+	}
+}
@@ -0,0 +1,21 @@
+package exporter
+
+import "github.com/fr0stylo/sfx/internal/rpc"
+
+// Metadata keys found in Request.Metadata. Times are RFC 3339 in UTC.
+const (
+	// MetaVersion identifies the version of the secret that was read.
+	MetaVersion = rpc.MetaVersion
+	// MetaCreated is when that version was created.
+	MetaCreated = rpc.MetaCreated
+	// MetaUpdated is when the secret was last changed.
+	MetaUpdated = rpc.MetaUpdated
+	// MetaExpires is when the secret stops being valid.
+	MetaExpires = rpc.MetaExpires
+	// MetaContentType is the media type recorded for the value.
+	MetaContentType = rpc.MetaContentType
+	// MetaProvider names the configured provider that returned the secret.
+	MetaProvider = rpc.MetaProvider
+	// MetaRef is the ref the secret was read from.
+	MetaRef = rpc.MetaRef
+)
@@ -0,0 +1,100 @@
+package exporter
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+	"github.com/fr0stylo/sfx/internal/schema"
+)
+
+// Mode is the process lifecycle a plugin prefers the host to use.
+type Mode string
+
+const (
+	// ModePersistent asks the host to keep the plugin running across requests.
+	ModePersistent Mode = rpc.ModePersistent
+	// ModeOneShot asks the host to start a fresh plugin process for every request.
+	ModeOneShot Mode = rpc.ModeOneShot
+)
+
+// Option customises how Run advertises the plugin to the host.
+type Option func(*settings)
+
+// WithMode advertises the preferred execution mode in the handshake.
+// Configuration on the host side takes precedence.
+func WithMode(m Mode) Option {
+	return func(s *settings) {
+		s.mode = m
+	}
+}
+
+// WithVersion overrides the version reported to the host.
+// By default the main module version from the binary's build info is used.
+func WithVersion(version string) Option {
+	return func(s *settings) {
+		s.version = version
+	}
+}
+
+// WithOptions declares the options accepted by the plugin. v is usually the zero
+// value of the options struct; its yaml and desc tags are published to the host
+// as a JSON Schema.
+func WithOptions(v any) Option {
+	return func(s *settings) {
+		s.options = v
+	}
+}
+
+// DecodeOptions decodes the options of a request into a T, usually the struct
+// passed to WithOptions. Unknown keys are rejected, `default:"..."` tags fill
+// options the host left unset, fields tagged `required:"true"` must be given a
+// non-empty value and time.Duration fields must hold non-negative durations such
+// as "30s". The error names every offending option.
+func DecodeOptions[T any](raw []byte) (T, error) {
+	var v T
+	err := schema.Decode(raw, &v)
+	return v, err
+}
+
+type settings struct {
+	mode    Mode
+	version string
+	options any
+}
+
+func newSettings(opts []Option) settings {
+	var s settings
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+func (s settings) handshake() (*rpc.Handshake, error) {
+	hs := &rpc.Handshake{
+		Kind:       "exporter",
+		Mode:       string(s.mode),
+		Version:    s.version,
+		StderrSync: true,
+	}
+	if hs.Version == "" {
+		hs.Version = buildVersion()
+	}
+	if s.options != nil {
+		raw, err := schema.Marshal(s.options)
+		if err != nil {
+			return nil, fmt.Errorf("describe options: %w", err)
+		}
+		hs.OptionsSchema = raw
+	}
+	return hs, nil
+}
+
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "(devel)" {
+		return ""
+	}
+	return info.Main.Version
+}
@@ -2,7 +2,11 @@
 package exporter
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 
 	"github.com/fr0stylo/sfx/internal/rpc"
@@ -12,6 +16,16 @@ import (
 type Request struct {
 	Values  map[string][]byte
 	Options []byte
+	// Keys lists the secret names in the order they are declared in the
+	// configuration. Hosts that predate it leave it empty.
+	Keys []string
+	// Metadata holds, per secret name, what the provider reported about the
+	// value (see the Meta* keys) along with MetaProvider and MetaRef.
+	Metadata map[string]map[string]string
+	// Types holds the declared type of each secret that has one (see the Type*
+	// constants and ParseValue). Exporters that produce typed output should
+	// honour it rather than guess from the value.
+	Types map[string]string
 }
 
 // Handler processes the provided values and returns an error if export fails.
@@ -19,9 +33,25 @@ type Handler interface {
 	Handle(Request) (Response, error)
 }
 
-// Response represents the binary payload produced by the exporter.
+// Response represents the output produced by the exporter: a payload written
+// to stdout, files written under the host's output directory, or both.
 type Response struct {
 	Payload []byte
+	Files   []File
+	// Prune asks the host to remove the files an earlier export with Prune
+	// wrote under the output directory that Files no longer includes, so
+	// secrets that were dropped from the configuration do not linger. Only
+	// files the host wrote itself are removed.
+	Prune bool
+}
+
+// File is a file for the host to write. Path is slash-separated and relative
+// to the output directory; the host refuses paths that leave it.
+type File struct {
+	Path    string
+	Content []byte
+	// Mode holds the permission bits; zero lets the host choose (0600).
+	Mode fs.FileMode
 }
 
 // HandlerFunc adapts a function to the Handler interface.
@@ -32,27 +62,102 @@ func (f HandlerFunc) Handle(req Request) (Response, error) {
 	return f(req)
 }
 
-// Run wires stdin/stdout to the protobuf transport and invokes the provided handler.
-func Run(h Handler) {
+// Run wires stdin/stdout to the plugin transport and invokes the provided handler.
+// The wire protocol (length-delimited protobuf or JSON lines) is chosen by the host.
+// When started outside the host with SFX_PLUGIN_LISTEN set, it calls Serve on
+// that address instead.
+func Run(h Handler, opts ...Option) {
+	if err := run(h, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+}
+
+func run(h Handler, opts []Option) error {
+	if addr := rpc.HostListenAddr(); addr != "" && !rpc.HostManaged() {
+		return listenAndServe(addr, h, opts)
+	}
+
+	codec, err := rpc.HostCodec()
+	if err != nil {
+		return err
+	}
+	return serve(codec, bufio.NewReader(os.Stdin), os.Stdout, h, newSettings(opts))
+}
+
+func serve(c rpc.Codec, in io.Reader, out io.Writer, h Handler, s settings) error {
+	hs, err := s.handshake()
+	if err != nil {
+		return err
+	}
+	if err := rpc.WriteHandshake(c, out, hs); err != nil {
+		return fmt.Errorf("write handshake: %w", err)
+	}
+	return serveRequests(c, in, out, h)
+}
+
+// ServeStream speaks the host's stdio protocol on in and out until in is
+// exhausted: it writes the handshake, then answers every request with h.
+// protocol selects the framing ("protobuf", the default, or "jsonl"). Unlike Run
+// it always announces itself, which lets tests and custom transports drive a
+// handler exactly as the host would.
+func ServeStream(protocol string, in io.Reader, out io.Writer, h Handler, opts ...Option) error {
+	c, err := rpc.CodecFor(protocol)
+	if err != nil {
+		return err
+	}
+	hs, err := newSettings(opts).handshake()
+	if err != nil {
+		return err
+	}
+	hs.ProtocolVersion = rpc.ProtocolVersion
+	if err := c.WriteMessage(out, hs); err != nil {
+		return fmt.Errorf("write handshake: %w", err)
+	}
+	return serveRequests(c, in, out, h)
+}
+
+func serveRequests(c rpc.Codec, in io.Reader, out io.Writer, h Handler) error {
 	for {
 		req := &rpc.ExportRequest{}
-		if err := rpc.ReadDelimited(os.Stdin, req); err != nil {
-			writeError(fmt.Errorf("decode request: %w", err))
-			return
+		if err := c.ReadMessage(in, req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			writeError(c, out, fmt.Errorf("decode request: %w", err))
+			return nil
 		}
 
-		resp, err := h.Handle(Request{Values: req.GetValues(), Options: req.GetOptions()})
+		resp, err := dispatch(h, req)
+		rpc.WriteStderrSync(os.Stderr)
 		if err != nil {
-			writeError(err)
-			return
+			writeError(c, out, err)
+			continue
 		}
 
-		if err := rpc.WriteDelimited(os.Stdout, &rpc.ExportResponse{Payload: resp.Payload}); err != nil {
-			fmt.Fprintf(os.Stderr, "write response: %v\n", err)
+		if err := c.WriteMessage(out, resp); err != nil {
+			return fmt.Errorf("write response: %w", err)
 		}
 	}
 }
 
-func writeError(err error) {
-	_ = rpc.WriteDelimited(os.Stdout, &rpc.ExportResponse{Error: err.Error()})
+func dispatch(h Handler, req *rpc.ExportRequest) (*rpc.ExportResponse, error) {
+	resp, err := h.Handle(Request{
+		Values:   req.GetValues(),
+		Options:  req.GetOptions(),
+		Keys:     req.GetKeys(),
+		Metadata: rpc.UnwrapMetadata(req.GetMetadata()),
+		Types:    req.GetTypes(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := &rpc.ExportResponse{Payload: resp.Payload, Prune: resp.Prune}
+	for _, f := range resp.Files {
+		out.Files = append(out.Files, &rpc.File{Path: f.Path, Content: f.Content, Mode: uint32(f.Mode.Perm())})
+	}
+	return out, nil
+}
+
+func writeError(c rpc.Codec, w io.Writer, err error) {
+	_ = c.WriteMessage(w, &rpc.ExportResponse{Error: err.Error()})
 }
@@ -0,0 +1,43 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+func TestServeStreamContinuesAfterHandlerError(t *testing.T) {
+	h := HandlerFunc(func(req Request) (Response, error) {
+		if _, ok := req.Values["bad"]; ok {
+			return Response{}, errors.New("cannot export bad")
+		}
+		return Response{Payload: []byte("ok")}, nil
+	})
+
+	c, _ := rpc.CodecFor(rpc.ProtocolProtobuf)
+	var in, out bytes.Buffer
+	for _, key := range []string{"good", "bad", "good"} {
+		if err := c.WriteMessage(&in, &rpc.ExportRequest{Values: map[string][]byte{key: []byte("v")}}); err != nil {
+			t.Fatalf("WriteMessage returned error: %v", err)
+		}
+	}
+	if err := ServeStream(rpc.ProtocolProtobuf, &in, &out, h); err != nil {
+		t.Fatalf("ServeStream returned error: %v", err)
+	}
+
+	if _, err := rpc.ReadHandshake(c, &out); err != nil {
+		t.Fatalf("ReadHandshake returned error: %v", err)
+	}
+	want := []*rpc.ExportResponse{{Payload: []byte("ok")}, {Error: "cannot export bad"}, {Payload: []byte("ok")}}
+	for i, w := range want {
+		got := &rpc.ExportResponse{}
+		if err := c.ReadMessage(&out, got); err != nil {
+			t.Fatalf("response %d: ReadMessage returned error: %v", i+1, err)
+		}
+		if string(got.GetPayload()) != string(w.GetPayload()) || got.GetError() != w.GetError() {
+			t.Fatalf("response %d = %v, want %v", i+1, got, w)
+		}
+	}
+}
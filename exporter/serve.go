@@ -0,0 +1,60 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// Serve answers requests from remote sfx hosts on l until l is closed. It is the
+// long-lived counterpart to Run: hosts reach it through a unix:// or http://
+// plugin path. Requests may arrive concurrently, so h must be safe for concurrent use.
+// It returns once l is closed and the requests in flight have completed.
+func Serve(l net.Listener, h Handler, opts ...Option) error {
+	hs, err := newSettings(opts).handshake()
+	if err != nil {
+		return err
+	}
+	hs.ProtocolVersion = rpc.ProtocolVersion
+	hs.StderrSync = false
+
+	srv := &http.Server{
+		Handler: rpc.HTTPHandler{
+			Handshake:  hs,
+			NewRequest: func() proto.Message { return &rpc.ExportRequest{} },
+			Call: func(_ *http.Request, req proto.Message) proto.Message {
+				resp, err := dispatch(h, req.(*rpc.ExportRequest))
+				if err != nil {
+					return &rpc.ExportResponse{Error: err.Error()}
+				}
+				return resp
+			},
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	err = srv.Serve(l)
+	if errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+	// Serve returns as soon as l is closed; let the requests in flight finish.
+	if serr := srv.Shutdown(context.Background()); serr != nil && !errors.Is(serr, net.ErrClosed) {
+		err = errors.Join(err, serr)
+	}
+	return err
+}
+
+// listenAndServe serves h on the address requested through SFX_PLUGIN_LISTEN.
+func listenAndServe(addr string, h Handler, opts []Option) error {
+	l, err := rpc.Listen(addr)
+	if err != nil {
+		return err
+	}
+	return Serve(l, h, opts...)
+}
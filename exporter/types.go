@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Value types a secret can be declared with in the configuration, found in
+// Request.Types.
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeBool   = "bool"
+	TypeJSON   = "json"
+	TypeBinary = "binary"
+)
+
+// ParseValue converts raw to the Go value of the declared type typ: a string
+// for TypeString, an int64 for TypeInt, a bool for TypeBool, the decoded
+// document (map[string]any, []any, string, number, bool or nil) for TypeJSON
+// and raw itself for TypeBinary. JSON numbers are exact: integers become int64
+// or uint64, or stay a json.Number beyond that range, and other numbers become
+// float64. An undeclared type yields a string. Errors do not quote the value,
+// which is a secret.
+func ParseValue(typ string, raw []byte) (any, error) {
+	switch typ {
+	case "", TypeString:
+		return string(raw), nil
+	case TypeInt:
+		v, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+		if err != nil {
+			return nil, errors.New("value declared as int is not an integer")
+		}
+		return v, nil
+	case TypeBool:
+		v, err := strconv.ParseBool(strings.TrimSpace(string(raw)))
+		if err != nil {
+			return nil, errors.New("value declared as bool is not true or false")
+		}
+		return v, nil
+	case TypeJSON:
+		v, err := decodeJSON(raw)
+		if err != nil {
+			var syntax *json.SyntaxError
+			if errors.As(err, &syntax) {
+				return nil, fmt.Errorf("value declared as json is not valid JSON (syntax error at offset %d)", syntax.Offset)
+			}
+			return nil, errors.New("value declared as json is not valid JSON")
+		}
+		return v, nil
+	case TypeBinary:
+		return raw, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q (want %s, %s, %s, %s or %s)", typ, TypeString, TypeInt, TypeBool, TypeJSON, TypeBinary)
+	}
+}
+
+// decodeJSON decodes a single JSON document, keeping numbers exact.
+func decodeJSON(raw []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("trailing data after JSON document")
+	}
+	return numbers(v), nil
+}
+
+// numbers replaces the json.Number values in v with int64, uint64 or float64.
+func numbers(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(val.String(), 10, 64); err == nil {
+			return u
+		}
+		if strings.ContainsAny(val.String(), ".eE") {
+			if f, err := val.Float64(); err == nil {
+				return f
+			}
+		}
+		return val
+	case []any:
+		for i, e := range val {
+			val[i] = numbers(e)
+		}
+	case map[string]any:
+		for k, e := range val {
+			val[k] = numbers(e)
+		}
+	}
+	return v
+}
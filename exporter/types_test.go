@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		typ  string
+		raw  string
+		want any
+	}{
+		{typ: "", raw: "01234", want: "01234"},
+		{typ: TypeString, raw: "true", want: "true"},
+		{typ: TypeInt, raw: " 42\n", want: int64(42)},
+		{typ: TypeBool, raw: "false", want: false},
+		{typ: TypeJSON, raw: `{"a":[1,"x"]}`, want: map[string]any{"a": []any{int64(1), "x"}}},
+		{typ: TypeJSON, raw: `{"id":9007199254740993,"n":-12,"f":1.5}`, want: map[string]any{"id": int64(9007199254740993), "n": int64(-12), "f": 1.5}},
+		{typ: TypeJSON, raw: `[18446744073709551615, 123456789012345678901234567890]`, want: []any{uint64(18446744073709551615), json.Number("123456789012345678901234567890")}},
+		{typ: TypeBinary, raw: "\x00\x01", want: []byte{0, 1}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseValue(tt.typ, []byte(tt.raw))
+		if err != nil {
+			t.Fatalf("ParseValue(%q, %q) returned error: %v", tt.typ, tt.raw, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("ParseValue(%q, %q) = %#v, want %#v", tt.typ, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseValueErrorsOmitValue(t *testing.T) {
+	for typ, raw := range map[string]string{TypeInt: "s3cret", TypeBool: "s3cret", TypeJSON: "{s3cret"} {
+		_, err := ParseValue(typ, []byte(raw))
+		if err == nil {
+			t.Fatalf("ParseValue(%q) accepted %q", typ, raw)
+		}
+		if strings.Contains(err.Error(), "s3cret") {
+			t.Fatalf("ParseValue(%q) error leaks the value: %v", typ, err)
+		}
+	}
+	if _, err := ParseValue(TypeJSON, []byte(`{"a":1} {}`)); err == nil {
+		t.Fatal("ParseValue accepted trailing data after a JSON document")
+	}
+	if _, err := ParseValue("float", nil); err == nil {
+		t.Fatal("ParseValue accepted an unknown type")
+	}
+}
@@ -0,0 +1,105 @@
+// Package builtin keeps the registry of plugins linked into the sfx binary.
+// Builtin plugins are addressed as builtin:<name> and called in-process,
+// bypassing the pipe transport.
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// Prefix marks a plugin path that refers to a builtin plugin.
+const Prefix = "builtin:"
+
+// CallFunc handles a single request in-process. Handler errors are reported
+// through the response message, exactly as plugin binaries do.
+type CallFunc func(ctx context.Context, req proto.Message, resp proto.Message) error
+
+// Plugin is a handler linked into the host binary.
+type Plugin struct {
+	Handshake *rpc.Handshake
+	Call      CallFunc
+	// Shutdown, if set, releases the plugin's resources when the host exits.
+	Shutdown func(context.Context) error
+}
+
+var (
+	mu      sync.RWMutex
+	plugins = map[string]Plugin{}
+)
+
+func key(kind, name string) string {
+	return kind + "/" + name
+}
+
+// Register adds a plugin of the given kind ("provider" or "exporter").
+// It panics if the name is already registered for that kind.
+func Register(kind, name string, p Plugin) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	k := key(kind, name)
+	if _, dup := plugins[k]; dup {
+		panic(fmt.Sprintf("builtin: %s %q registered twice", kind, name))
+	}
+	plugins[k] = p
+}
+
+// Lookup returns the builtin plugin registered under kind and name.
+func Lookup(kind, name string) (Plugin, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	p, ok := plugins[key(kind, name)]
+	return p, ok
+}
+
+// Names returns the sorted names of builtin plugins of the given kind.
+func Names(kind string) []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var names []string
+	for k := range plugins {
+		if name, ok := strings.CutPrefix(k, kind+"/"); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Shutdown runs the Shutdown hook of every registered plugin.
+func Shutdown(ctx context.Context) error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var errs []error
+	for k, p := range plugins {
+		if p.Shutdown == nil {
+			continue
+		}
+		if err := p.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("builtin %s: %w", k, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Path returns the plugin path that refers to the named builtin.
+func Path(name string) string {
+	return Prefix + name
+}
+
+// ParsePath reports whether path refers to a builtin plugin and returns its name.
+func ParsePath(path string) (string, bool) {
+	return strings.CutPrefix(path, Prefix)
+}
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fr0stylo/sfx/internal/builtin"
+)
+
+// lookupBuiltin reports whether spec refers to a builtin plugin and returns it.
+// The error is set when the path uses the builtin: prefix but no such plugin is linked in.
+func lookupBuiltin(spec Spec) (builtin.Plugin, bool, error) {
+	name, ok := builtin.ParsePath(spec.Path)
+	if !ok {
+		return builtin.Plugin{}, false, nil
+	}
+
+	p, ok := builtin.Lookup(spec.Kind, name)
+	if !ok {
+		return builtin.Plugin{}, true, fmt.Errorf("%s %q is not built into this binary", spec.Kind, name)
+	}
+	return p, true, nil
+}
+
+func callBuiltin(ctx context.Context, p builtin.Plugin, req proto.Message, resp proto.Message) error {
+	if resp == nil {
+		return errors.New("client: response message must not be nil")
+	}
+	return p.Call(ctx, req, resp)
+}
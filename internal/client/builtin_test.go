@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fr0stylo/sfx/internal/builtin"
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+func TestCallContextDispatchesBuiltinInProcess(t *testing.T) {
+	builtin.Register("provider", "echo-test", builtin.Plugin{
+		Handshake: &rpc.Handshake{Kind: "provider"},
+		Call: func(_ context.Context, req proto.Message, resp proto.Message) error {
+			resp.(*rpc.SecretResponse).Value = []byte(req.(*rpc.SecretRequest).GetRef())
+			return nil
+		},
+	})
+
+	spec := Spec{Name: "echo", Kind: "provider", Path: builtin.Path("echo-test")}
+	var resp rpc.SecretResponse
+	if err := CallContext(context.Background(), spec, &rpc.SecretRequest{Ref: "hello"}, &resp); err != nil {
+		t.Fatalf("CallContext returned error: %v", err)
+	}
+	if got := string(resp.GetValue()); got != "hello" {
+		t.Fatalf("value = %q, want %q", got, "hello")
+	}
+
+	hs, err := Describe(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("Describe returned error: %v", err)
+	}
+	if hs.GetKind() != "provider" {
+		t.Fatalf("kind = %q, want provider", hs.GetKind())
+	}
+}
+
+func TestCallContextRejectsUnknownBuiltin(t *testing.T) {
+	spec := Spec{Name: "ghost", Kind: "exporter", Path: builtin.Path("ghost")}
+
+	err := CallContext(context.Background(), spec, &rpc.ExportRequest{}, &rpc.ExportResponse{})
+	if err == nil || !strings.Contains(err.Error(), "not built into this binary") {
+		t.Fatalf("expected missing builtin error, got %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// conformanceRequests is how many identical requests a plugin must answer on one process.
+const conformanceRequests = 3
+
+// oversizedLen is the size of the ref (or value) used to probe request size handling.
+const oversizedLen = 1 << 20
+
+// ConformanceProbe is the input the conformance scenario sends to a plugin.
+type ConformanceProbe struct {
+	// Ref and Options are sent to providers.
+	Ref     string
+	Options []byte
+	// Values and Options are sent to exporters.
+	Values map[string][]byte
+	// Timeout bounds every step; a plugin that does not answer in time is killed.
+	Timeout time.Duration
+}
+
+// ConformanceResult is the outcome of one step of the conformance scenario.
+type ConformanceResult struct {
+	Step string
+	// Err describes the violation; nil means the step passed.
+	Err error
+	// Detail summarises what the plugin answered.
+	Detail string
+}
+
+// Conformance drives the plugin binary described by spec through the scenario
+// the host relies on: a handshake, several requests on one process, a request
+// without options, an oversized request, a clean exit on EOF and, on a second
+// process, an error response to a malformed request. Error responses are fine
+// wherever a response is expected; what matters is that the plugin answers and
+// keeps serving.
+func Conformance(ctx context.Context, spec Spec, probe ConformanceProbe) []ConformanceResult {
+	if probe.Timeout <= 0 {
+		probe.Timeout = 10 * time.Second
+	}
+
+	var results []ConformanceResult
+	record := func(step string, detail string, err error) {
+		results = append(results, ConformanceResult{Step: step, Detail: detail, Err: err})
+	}
+
+	s, err := startSession(ctx, spec, probe.Timeout)
+	if err != nil {
+		record("handshake", "", err)
+		return results
+	}
+	hs := s.p.Handshake()
+	record("handshake", fmt.Sprintf("%s, protocol %d, mode %s", hs.GetKind(), hs.GetProtocolVersion(), valueOr(hs.GetMode(), "default")), nil)
+
+	// One-shot plugins may exit after each request, so every step gets a fresh
+	// process and the checks that need a long-lived one are skipped.
+	kind := hs.GetKind()
+	oneShot := hs.GetMode() == rpc.ModeOneShot
+	steps := []struct {
+		name       string
+		req        func() proto.Message
+		n          int
+		persistent bool
+	}{
+		{"sequential requests", func() proto.Message { return probeRequest(kind, probe, probe.Options) }, conformanceRequests, true},
+		{"empty options", func() proto.Message { return probeRequest(kind, probe, nil) }, 1, false},
+		{"oversized " + oversizedField(kind), func() proto.Message { return oversizedRequest(kind, probe) }, 1, false},
+		{"request after errors", func() proto.Message { return probeRequest(kind, probe, probe.Options) }, 1, true},
+	}
+	for _, step := range steps {
+		if oneShot && step.persistent {
+			record(step.name, "skipped: plugin runs one-shot", nil)
+			continue
+		}
+		if s == nil {
+			if s, err = startSession(ctx, spec, probe.Timeout); err != nil {
+				record(step.name, "", err)
+				return results
+			}
+		}
+
+		var detail string
+		var err error
+		for i := 0; i < step.n && err == nil; i++ {
+			detail, err = s.call(kind, step.req())
+			if err != nil && step.n > 1 {
+				err = fmt.Errorf("request %d of %d: %w", i+1, step.n, err)
+			}
+		}
+		if step.n > 1 && err == nil {
+			detail = fmt.Sprintf("%d responses, last: %s", step.n, detail)
+		}
+		if err == nil && oneShot {
+			err = s.shutdown()
+			s = nil
+		}
+		record(step.name, detail, err)
+		if err != nil {
+			if s != nil {
+				s.close()
+			}
+			return results
+		}
+	}
+
+	if !oneShot {
+		if err := s.shutdown(); err != nil {
+			record("exit on EOF", "", err)
+		} else {
+			record("exit on EOF", "exited cleanly", nil)
+		}
+	}
+
+	detail, err := malformedRequest(ctx, spec, probe.Timeout)
+	record("malformed request", detail, err)
+
+	return results
+}
+
+// session is a plugin process whose every step is bounded by a timeout.
+type session struct {
+	p       *Process
+	codec   rpc.Codec
+	timeout time.Duration
+	kill    context.CancelFunc
+}
+
+func startSession(ctx context.Context, spec Spec, timeout time.Duration) (*session, error) {
+	codec, err := rpc.CodecFor(spec.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, kill := context.WithCancel(ctx)
+	s := &session{codec: codec, timeout: timeout, kill: kill}
+	err = s.within(func() error {
+		p, err := StartProcess(ctx, spec)
+		s.p = p
+		return err
+	})
+	if err != nil {
+		kill()
+		return nil, err
+	}
+	if s.p.Handshake().GetKind() == "" {
+		s.close()
+		return nil, errors.New("handshake does not report the plugin kind")
+	}
+	return s, nil
+}
+
+// within runs f and kills the plugin if it does not return in time.
+func (s *session) within(f func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- f() }()
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		s.kill()
+		<-done
+		return fmt.Errorf("no answer within %s", s.timeout)
+	}
+}
+
+// call sends req and describes the response. Error responses are not violations.
+func (s *session) call(kind string, req proto.Message) (string, error) {
+	resp := newResponse(kind)
+	if err := s.within(func() error { return s.p.Call(context.Background(), req, resp) }); err != nil {
+		return "", err
+	}
+	return describeResponse(resp), nil
+}
+
+// shutdown closes stdin and expects the plugin to exit successfully.
+func (s *session) shutdown() error {
+	defer s.kill()
+	if err := s.within(s.p.Close); err != nil {
+		return fmt.Errorf("did not exit cleanly after EOF: %w", err)
+	}
+	return nil
+}
+
+func (s *session) close() {
+	s.kill()
+	_ = s.p.Close()
+}
+
+// malformedRequest starts a fresh process, sends a request that cannot be decoded
+// and expects an error response. The plugin may exit afterwards.
+func malformedRequest(ctx context.Context, spec Spec, timeout time.Duration) (string, error) {
+	s, err := startSession(ctx, spec, timeout)
+	if err != nil {
+		return "", err
+	}
+	defer s.close()
+
+	garbage := []byte{3, 0xff, 0xff, 0xff}
+	if spec.Protocol == rpc.ProtocolJSONL {
+		garbage = []byte("{not json\n")
+	}
+
+	kind := s.p.Handshake().GetKind()
+	resp := newResponse(kind)
+	err = s.within(func() error {
+		if _, err := s.p.in.Write(garbage); err != nil {
+			return fmt.Errorf("send request: %w", err)
+		}
+		return s.codec.ReadMessage(s.p.reader, resp)
+	})
+	if err != nil {
+		return "", fmt.Errorf("no error response: %w", err)
+	}
+
+	msg := responseError(resp)
+	if msg == "" {
+		return "", errors.New("answered a malformed request without an error")
+	}
+	return "error response: " + truncate(msg), nil
+}
+
+func probeRequest(kind string, probe ConformanceProbe, options []byte) proto.Message {
+	if kind == "exporter" {
+		return &rpc.ExportRequest{Values: probe.Values, Options: options}
+	}
+	return &rpc.SecretRequest{Ref: probe.Ref, Options: options}
+}
+
+func oversizedRequest(kind string, probe ConformanceProbe) proto.Message {
+	big := strings.Repeat("x", oversizedLen)
+	if kind == "exporter" {
+		return &rpc.ExportRequest{Values: map[string][]byte{"OVERSIZED": []byte(big)}, Options: probe.Options}
+	}
+	return &rpc.SecretRequest{Ref: probe.Ref + big, Options: probe.Options}
+}
+
+func oversizedField(kind string) string {
+	if kind == "exporter" {
+		return "value"
+	}
+	return "ref"
+}
+
+func newResponse(kind string) proto.Message {
+	if kind == "exporter" {
+		return &rpc.ExportResponse{}
+	}
+	return &rpc.SecretResponse{}
+}
+
+func responseError(resp proto.Message) string {
+	switch r := resp.(type) {
+	case *rpc.SecretResponse:
+		return r.GetError()
+	case *rpc.ExportResponse:
+		return r.GetError()
+	}
+	return ""
+}
+
+func describeResponse(resp proto.Message) string {
+	if msg := responseError(resp); msg != "" {
+		return "error response: " + truncate(msg)
+	}
+	switch r := resp.(type) {
+	case *rpc.SecretResponse:
+		return fmt.Sprintf("value (%d bytes)", len(r.GetValue()))
+	case *rpc.ExportResponse:
+		return fmt.Sprintf("payload (%d bytes)", len(r.GetPayload()))
+	}
+	return ""
+}
+
+func valueOr(v, fallback string) string {
+	if v == "" {
+		return fallback
+	}
+	return v
+}
+
+// truncate shortens plugin messages that echo an oversized request back.
+func truncate(msg string) string {
+	const limit = 120
+	if len(msg) <= limit {
+		return msg
+	}
+	return msg[:limit] + "..."
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// jsonlPlugin answers every JSON line with a value and anything else with an
+// error; with once set it exits after the first request.
+func jsonlPlugin(t *testing.T, once bool) string {
+	t.Helper()
+
+	exit := ""
+	if once {
+		exit = "exit 0"
+	}
+	script := `#!/bin/sh
+echo '{"protocol_version":1,"kind":"provider"}'
+while IFS= read -r line; do
+	case "$line" in
+	'{'*'}') echo '{"value":"YQ=="}' ;;
+	*) echo '{"error":"malformed request"}' ;;
+	esac
+	` + exit + `
+done
+`
+	path := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+	return path
+}
+
+func TestConformancePassesWellBehavedPlugin(t *testing.T) {
+	spec := Spec{Name: "ok", Kind: "provider", Path: jsonlPlugin(t, false), Protocol: rpc.ProtocolJSONL}
+	probe := ConformanceProbe{Ref: "probe://a", Timeout: 10 * time.Second}
+
+	results := Conformance(context.Background(), spec, probe)
+	if len(results) != 7 {
+		t.Fatalf("got %d steps, want 7: %+v", len(results), results)
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("step %q failed: %v", r.Step, r.Err)
+		}
+	}
+}
+
+func TestConformanceReportsPluginExitingAfterOneRequest(t *testing.T) {
+	spec := Spec{Name: "quits", Kind: "provider", Path: jsonlPlugin(t, true), Protocol: rpc.ProtocolJSONL}
+	probe := ConformanceProbe{Ref: "probe://a", Timeout: 10 * time.Second}
+
+	results := Conformance(context.Background(), spec, probe)
+	last := results[len(results)-1]
+	if last.Step != "sequential requests" || last.Err == nil || !strings.Contains(last.Err.Error(), "request 2 of 3") {
+		t.Fatalf("last step = %+v, want sequential requests failing on request 2", last)
+	}
+}
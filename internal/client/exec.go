@@ -4,61 +4,76 @@ package client
 import (
 	"context"
 	"errors"
-	"fmt"
-	"os"
-	"os/exec"
 
 	"google.golang.org/protobuf/proto"
 
 	"github.com/fr0stylo/sfx/internal/rpc"
 )
 
-// Call executes the binary at path, sending the protobuf request and decoding the response.
+// Call spawns the plugin described by spec, performs a single request/response
+// exchange and waits for the process to exit.
 // The response parameter must be a pointer to the expected message type.
-func Call(ctx context.Context, path string, req proto.Message, resp proto.Message) error {
+func Call(ctx context.Context, spec Spec, req proto.Message, resp proto.Message) error {
 	if resp == nil {
 		return errors.New("client: response message must not be nil")
 	}
 
-	cmd := exec.CommandContext(ctx, path)
-	cmd.Env = os.Environ()
+	if b, ok, err := lookupBuiltin(spec); ok {
+		if err != nil {
+			return err
+		}
+		return callBuiltin(ctx, b, req, resp)
+	}
+	if r, ok, err := lookupRemote(spec); ok {
+		if err != nil {
+			return err
+		}
+		return r.call(ctx, spec, req, resp)
+	}
 
-	ip, err := cmd.StdinPipe()
+	p, err := StartProcess(ctx, spec)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		_ = ip.Close()
-	}()
 
-	op, err := cmd.StdoutPipe()
-	if err != nil {
+	return oneShot(ctx, p, req, resp)
+}
+
+// oneShot sends a single request to p and shuts it down afterwards.
+func oneShot(ctx context.Context, p *Process, req proto.Message, resp proto.Message) error {
+	if err := p.Call(ctx, req, resp); err != nil {
+		_ = p.Close()
 		return err
 	}
 
-	cmd.Stderr = os.Stderr
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("start command: %w", err)
-	}
+	return p.Close()
+}
 
-	if req != nil {
-		if err := rpc.WriteDelimited(ip, req); err != nil {
-			_ = cmd.Wait()
-			return fmt.Errorf("send request: %w", err)
+// Describe returns the handshake of the plugin described by spec. Plugin binaries
+// are checked for executability, started and shut down again.
+func Describe(ctx context.Context, spec Spec) (*rpc.Handshake, error) {
+	if p, ok, err := lookupBuiltin(spec); ok {
+		if err != nil {
+			return nil, err
 		}
+		return p.Handshake, nil
 	}
-	if err := ip.Close(); err != nil {
-		return err
+	if r, ok, err := lookupRemote(spec); ok {
+		if err != nil {
+			return nil, err
+		}
+		return r.handshake(ctx, spec)
 	}
 
-	if err := rpc.ReadDelimited(op, resp); err != nil {
-		_ = cmd.Wait()
-		return fmt.Errorf("read response: %w", err)
+	if err := CheckExecutable(spec.Path); err != nil {
+		return nil, err
 	}
 
-	if err := cmd.Wait(); err != nil {
-		return err
+	p, err := StartProcess(ctx, spec)
+	if err != nil {
+		return nil, err
 	}
+	hs := p.Handshake()
 
-	return nil
+	return hs, p.Close()
 }
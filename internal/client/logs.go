@@ -0,0 +1,89 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// stderrSyncTimeout bounds how long a call waits for the plugin's stderr to catch up.
+const stderrSyncTimeout = 2 * time.Second
+
+type secretKey struct{}
+
+// WithSecret annotates ctx with the secret a plugin call is serving, so that
+// anything the plugin logs during the call is attributed to it.
+func WithSecret(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, secretKey{}, name)
+}
+
+func secretFrom(ctx context.Context) string {
+	name, _ := ctx.Value(secretKey{}).(string)
+	return name
+}
+
+// forwardStderr re-emits plugin stderr through the host logger, one record per line.
+func (p *Process) forwardStderr(r io.Reader) {
+	defer close(p.stderrDone)
+
+	scan := bufio.NewScanner(r)
+	scan.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scan.Scan() {
+		line := scan.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if string(line) == rpc.StderrSyncMarker {
+			select {
+			case p.stderrSync <- struct{}{}:
+			default:
+			}
+			continue
+		}
+
+		logger := p.logger
+		if secret, _ := p.secret.Load().(string); secret != "" {
+			logger = logger.With("secret", secret)
+		}
+
+		if rec, ok := rpc.ParseLogRecord(line); ok {
+			logger.Log(context.Background(), rec.Level, rec.Message, rec.Attrs...)
+			continue
+		}
+		logger.Info(string(line))
+	}
+
+	// Keep draining so the plugin never blocks on a full stderr pipe.
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// awaitStderr waits until stderr written while serving the current request has
+// been forwarded, for plugins that advertise stderr synchronisation.
+func (p *Process) awaitStderr() {
+	if !p.handshake.GetStderrSync() {
+		return
+	}
+
+	timer := time.NewTimer(stderrSyncTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-p.stderrSync:
+	case <-p.stderrDone:
+	case <-timer.C:
+	}
+}
+
+// hostLogLevel reports the lowest level enabled on the default logger.
+func hostLogLevel() slog.Level {
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn} {
+		if slog.Default().Enabled(context.Background(), level) {
+			return level
+		}
+	}
+	return slog.LevelError
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// FileSHA256 returns the hex-encoded SHA-256 digest of the file at path.
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// openPinned opens the plugin binary at path and refuses it unless its digest
+// matches want. The caller starts the returned file rather than path, so the
+// binary cannot be swapped between the check and the start.
+func openPinned(path, want string) (*os.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("hash %s: %w", path, err)
+	}
+	if err := matchPin(path, want, h.Sum(nil)); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// matchPin refuses the plugin at path unless sum, its SHA-256 digest, matches
+// the hex-encoded want.
+func matchPin(path, want string, sum []byte) error {
+	if got := hex.EncodeToString(sum); !strings.EqualFold(got, want) {
+		return fmt.Errorf("plugin %s: sha256 mismatch (pinned %s, found %s)", path, want, got)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+func TestFileSHA256(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(path, []byte("hello\n"), 0o755); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	got, err := FileSHA256(path)
+	if err != nil {
+		t.Fatalf("FileSHA256 returned error: %v", err)
+	}
+	if want := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"; got != want {
+		t.Fatalf("FileSHA256 = %q, want %q", got, want)
+	}
+}
+
+func TestStartProcessRefusesPinMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugin")
+	writeExecutable(t, path)
+
+	spec := Spec{Name: "tampered", Kind: "provider", Path: path, SHA256: strings.Repeat("0", 64)}
+	_, err := StartProcess(context.Background(), spec)
+	if err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Fatalf("expected sha256 mismatch, got %v", err)
+	}
+}
+
+func TestStartProcessRunsPinnedFile(t *testing.T) {
+	path := jsonlPlugin(t, false)
+	sum, err := FileSHA256(path)
+	if err != nil {
+		t.Fatalf("FileSHA256 returned error: %v", err)
+	}
+
+	spec := Spec{Name: "pinned", Kind: "provider", Path: path, Protocol: rpc.ProtocolJSONL, SHA256: sum}
+	p, err := StartProcess(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("StartProcess returned error: %v", err)
+	}
+	defer p.Close()
+
+	resp := &rpc.SecretResponse{}
+	if err := p.Call(context.Background(), &rpc.SecretRequest{Ref: "a"}, resp); err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+	if string(resp.GetValue()) != "a" {
+		t.Fatalf("value = %q, want %q", resp.GetValue(), "a")
+	}
+}
@@ -1,47 +1,156 @@
 package client
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
-	"os/exec"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"google.golang.org/protobuf/proto"
 
 	"github.com/fr0stylo/sfx/internal/rpc"
 )
 
-// Process owns a spawned plugin binary and the pipes used for RPC communication.
+// Mode selects how plugin processes are reused across calls.
+type Mode string
+
+const (
+	// ModeAuto defers to the mode advertised in the plugin handshake, falling back to persistent.
+	ModeAuto Mode = ""
+	// ModePersistent keeps one process per plugin alive for the lifetime of the host.
+	ModePersistent Mode = rpc.ModePersistent
+	// ModeOneShot spawns a fresh process for every request.
+	ModeOneShot Mode = rpc.ModeOneShot
+)
+
+// Spec describes a plugin binary and how it should be executed.
+type Spec struct {
+	// Name is the configured plugin name, used to attribute log output.
+	Name string
+	// Kind is either "provider" or "exporter".
+	Kind string
+	Path string
+	Mode Mode
+	// Protocol selects the wire protocol, rpc.ProtocolProtobuf (default) or rpc.ProtocolJSONL.
+	Protocol string
+	// SHA256 pins the expected hex digest of the binary; it is checked before every start.
+	SHA256 string
+	// EnvAllow lists glob patterns of host variables passed to the plugin; nil
+	// passes the whole environment to native plugins and none to WebAssembly ones.
+	EnvAllow []string
+	// EnvSet adds or overrides NAME=value variables in the plugin environment.
+	EnvSet []string
+	// Dir is the plugin's working directory; empty uses the host's.
+	Dir string
+	// Limits are applied to the plugin process before it starts. WebAssembly
+	// plugins honour only the address space limit.
+	Limits Limits
+	// Mounts are the host directories visible to a WebAssembly plugin.
+	Mounts []Mount
+}
+
+// Process owns a running plugin, either a spawned binary or a WebAssembly module,
+// and the pipes used for RPC communication.
 type Process struct {
-	cmd *exec.Cmd
-	in  io.WriteCloser
-	out io.ReadCloser
+	wait       func() error
+	interrupt  func()
+	in         io.WriteCloser
+	out        io.ReadCloser
+	reader     *bufio.Reader
+	codec      rpc.Codec
+	handshake  *rpc.Handshake
+	logger     *slog.Logger
+	secret     atomic.Value
+	stderrSync chan struct{}
+	stderrDone chan struct{}
 }
 
-// StartProcess launches the plugin binary at path and returns a Process wrapper.
-func StartProcess(ctx context.Context, path string) (*Process, error) {
-	cmd := exec.CommandContext(ctx, path)
-	cmd.Env = os.Environ()
-	cmd.Stderr = os.Stderr
+// handshakeTimeout bounds how long a started plugin may take to announce itself.
+// Plugins built before the handshake existed never send one.
+var handshakeTimeout = 5 * time.Second
 
-	w, err := cmd.StdinPipe()
+// StartProcess launches the plugin binary described by spec and completes the handshake.
+// Pinned binaries are hashed first and refused on mismatch. A plugin that does not
+// complete the handshake within a few seconds is stopped.
+func StartProcess(ctx context.Context, spec Spec) (*Process, error) {
+	codec, err := rpc.CodecFor(spec.Protocol)
 	if err != nil {
 		return nil, err
 	}
-	r, err := cmd.StdoutPipe()
+	pipes, err := launch(ctx, spec)
 	if err != nil {
 		return nil, err
 	}
-	if err := cmd.Start(); err != nil {
-		return nil, err
+
+	p := &Process{
+		wait:       pipes.wait,
+		interrupt:  pipes.interrupt,
+		in:         pipes.stdin,
+		out:        pipes.stdout,
+		reader:     bufio.NewReader(pipes.stdout),
+		codec:      codec,
+		logger:     slog.Default().With("plugin", spec.Name, "kind", spec.Kind),
+		stderrSync: make(chan struct{}, 1),
+		stderrDone: make(chan struct{}),
+	}
+	p.secret.Store(secretFrom(ctx))
+	go p.forwardStderr(pipes.stderr)
+
+	hs, err := p.readHandshake(ctx, pipes.kill)
+	if err != nil {
+		_ = p.Close()
+		return nil, fmt.Errorf("plugin %s: %w", spec.Path, err)
 	}
+	if spec.Kind != "" && hs.GetKind() != "" && hs.GetKind() != spec.Kind {
+		_ = p.Close()
+		return nil, fmt.Errorf("plugin %s: configured as %s but reports kind %q", spec.Path, spec.Kind, hs.GetKind())
+	}
+	p.handshake = hs
+
+	return p, nil
+}
 
-	return &Process{
-		cmd: cmd,
-		in:  w,
-		out: r,
-	}, nil
+// readHandshake reads the plugin's handshake, calling kill to stop the plugin
+// when it does not arrive within handshakeTimeout or ctx ends first.
+func (p *Process) readHandshake(ctx context.Context, kill func()) (*rpc.Handshake, error) {
+	type result struct {
+		hs  *rpc.Handshake
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		hs, err := rpc.ReadHandshake(p.codec, p.reader)
+		done <- result{hs, err}
+	}()
+
+	timer := time.NewTimer(handshakeTimeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.hs, r.err
+	case <-timer.C:
+		kill()
+		return nil, fmt.Errorf("did not complete handshake within %s (built against an older SDK?)", handshakeTimeout)
+	case <-ctx.Done():
+		kill()
+		return nil, fmt.Errorf("handshake: %w", ctx.Err())
+	}
+}
+
+// Handshake returns the metadata the plugin announced on startup.
+func (p *Process) Handshake() *rpc.Handshake {
+	return p.handshake
+}
+
+// Mode reports the execution mode advertised by the plugin.
+func (p *Process) Mode() Mode {
+	return Mode(p.handshake.GetMode())
 }
 
 // Call performs a round-trip protobuf exchange with the running process.
@@ -50,17 +159,30 @@ func (p *Process) Call(ctx context.Context, req proto.Message, resp proto.Messag
 		return err
 	}
 
+	p.secret.Store(secretFrom(ctx))
+	defer p.secret.Store("")
+
 	if req != nil {
-		if err := rpc.WriteDelimited(p.in, req); err != nil {
-			_ = p.cmd.Wait()
+		if err := p.codec.WriteMessage(p.in, req); err != nil {
 			return fmt.Errorf("send request: %w", err)
 		}
 	}
 
-	if err := rpc.ReadDelimited(p.out, resp); err != nil {
-		_ = p.cmd.Wait()
+	// When ctx ends the plugin is interrupted, which the SDK turns into the
+	// cancellation of the handler's context, and stdin is closed so the read
+	// below ends once the plugin exits.
+	stop := context.AfterFunc(ctx, func() {
+		p.interrupt()
+		_ = p.in.Close()
+	})
+	err := p.codec.ReadMessage(p.reader, resp)
+	if !stop() {
+		return ctx.Err()
+	}
+	if err != nil {
 		return fmt.Errorf("read response: %w", err)
 	}
+	p.awaitStderr()
 
 	return nil
 }
@@ -69,6 +191,70 @@ func (p *Process) Call(ctx context.Context, req proto.Message, resp proto.Messag
 func (p *Process) Close() error {
 	_ = p.in.Close()
 	_ = p.out.Close()
+	<-p.stderrDone
+
+	return p.wait()
+}
+
+// pipes are the standard streams of a started plugin, a function that waits
+// for it to exit once stdin is closed, one that asks it to abandon the request
+// in flight and one that stops it outright.
+type pipes struct {
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	stderr    io.Reader
+	wait      func() error
+	interrupt func()
+	kill      func()
+}
+
+// launch starts the plugin described by spec: .wasm modules run in the embedded
+// WASI runtime, anything else is executed as a native binary. Pinned binaries
+// are hashed through the handle they are started from.
+func launch(ctx context.Context, spec Spec) (pipes, error) {
+	if isWasm(spec.Path) {
+		return launchWasm(ctx, spec)
+	}
+
+	path, err := pluginPath(spec)
+	if err != nil {
+		return pipes{}, err
+	}
+	exe, extra := path, []*os.File(nil)
+	if spec.SHA256 != "" {
+		f, err := openPinned(path, spec.SHA256)
+		if err != nil {
+			return pipes{}, err
+		}
+		defer f.Close()
+		exe, extra = pinnedExec(f)
+	}
+
+	// Plugins see their own path as argv[0], not the descriptor.
+	cmd, err := command(ctx, spec, exe, path, pluginEnv(spec))
+	if err != nil {
+		return pipes{}, err
+	}
+	cmd.Dir = spec.Dir
+	cmd.ExtraFiles = extra
+
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		return pipes{}, err
+	}
+	r, err := cmd.StdoutPipe()
+	if err != nil {
+		return pipes{}, err
+	}
+	e, err := cmd.StderrPipe()
+	if err != nil {
+		return pipes{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return pipes{}, err
+	}
 
-	return p.cmd.Wait()
+	interrupt := func() { _ = cmd.Process.Signal(syscall.SIGTERM) }
+	kill := func() { _ = cmd.Process.Kill() }
+	return pipes{stdin: w, stdout: r, stderr: e, wait: cmd.Wait, interrupt: interrupt, kill: kill}, nil
 }
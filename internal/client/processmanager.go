@@ -2,23 +2,103 @@ package client
 
 import (
 	"context"
+	"errors"
+	"sync"
 
 	"google.golang.org/protobuf/proto"
+
+	"github.com/fr0stylo/sfx/internal/builtin"
+)
+
+var (
+	poolMu sync.Mutex
+	pool   = make(map[string]*poolEntry)
 )
 
-var pool = make(map[string]*Process)
+// poolEntry holds the persistent process of one configured plugin. Its mutex
+// serialises calls to that process without blocking calls to other plugins.
+type poolEntry struct {
+	mu sync.Mutex
+	p  *Process
+}
+
+// CallContext satisfies a request/response pair according to the plugin's execution mode.
+// Persistent plugins are started once per configured plugin and reused; one-shot plugins get a
+// fresh process for every call. Builtin plugins are called in-process and remote
+// plugins over their unix socket or HTTP endpoint.
+func CallContext(ctx context.Context, spec Spec, req proto.Message, resp proto.Message) error {
+	if p, ok, err := lookupBuiltin(spec); ok {
+		if err != nil {
+			return err
+		}
+		return callBuiltin(ctx, p, req, resp)
+	}
+	if r, ok, err := lookupRemote(spec); ok {
+		if err != nil {
+			return err
+		}
+		return r.call(ctx, spec, req, resp)
+	}
+
+	if spec.Mode == ModeOneShot {
+		return Call(ctx, spec, req, resp)
+	}
 
-// CallContext reuses per-path plugin processes to satisfy request/response pairs.
-func CallContext(ctx context.Context, path string, req proto.Message, resp proto.Message) error {
-	mpt, ok := pool[path]
+	poolMu.Lock()
+	e, ok := pool[spec.poolKey()]
 	if !ok {
-		p, err := StartProcess(ctx, path)
+		e = &poolEntry{}
+		pool[spec.poolKey()] = e
+	}
+	poolMu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.p == nil {
+		p, err := StartProcess(ctx, spec)
 		if err != nil {
 			return err
 		}
-		mpt = p
-		pool[path] = p
+		if spec.Mode == ModeAuto && p.Mode() == ModeOneShot {
+			return oneShot(ctx, p, req, resp)
+		}
+		e.p = p
+	}
+
+	if err := e.p.Call(ctx, req, resp); err != nil {
+		_ = e.p.Close()
+		e.p = nil
+		return err
+	}
+
+	return nil
+}
+
+// poolKey identifies a persistent process. Plugins sharing a binary may differ in
+// environment and limits, so each configured plugin gets its own process.
+func (s Spec) poolKey() string {
+	return s.Kind + "/" + s.Name + "@" + s.Path
+}
+
+// Shutdown closes every pooled plugin process and waits for them to exit, then
+// runs the shutdown hooks of builtin plugins.
+func Shutdown() error {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+
+	errs := []error{builtin.Shutdown(context.Background())}
+	for key, e := range pool {
+		e.mu.Lock()
+		if e.p != nil {
+			if err := e.p.Close(); err != nil {
+				errs = append(errs, err)
+			}
+			e.p = nil
+		}
+		e.mu.Unlock()
+		delete(pool, key)
 	}
 
-	return mpt.Call(ctx, req, resp)
+	return errors.Join(errs...)
 }
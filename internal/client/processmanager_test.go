@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// pidPlugin announces mode in its handshake and answers every request with its
+// process ID in the response metadata.
+func pidPlugin(t *testing.T, mode string) string {
+	t.Helper()
+
+	script := `#!/bin/sh
+echo '{"protocol_version":1,"kind":"provider","mode":"` + mode + `"}'
+while IFS= read -r line; do
+	echo '{"value":"YQ==","metadata":{"pid":"'$$'"}}'
+done
+`
+	path := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+	return path
+}
+
+func TestCallContextModeSelection(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      Mode
+		announced string
+		reused    bool
+	}{
+		{name: "auto defaults to persistent", mode: ModeAuto, announced: "", reused: true},
+		{name: "auto honours persistent", mode: ModeAuto, announced: rpc.ModePersistent, reused: true},
+		{name: "auto honours oneshot", mode: ModeAuto, announced: rpc.ModeOneShot, reused: false},
+		{name: "persistent overrides plugin", mode: ModePersistent, announced: rpc.ModeOneShot, reused: true},
+		{name: "oneshot overrides plugin", mode: ModeOneShot, announced: rpc.ModePersistent, reused: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { _ = Shutdown() })
+			spec := Spec{Name: tt.name, Kind: "provider", Path: pidPlugin(t, tt.announced), Mode: tt.mode, Protocol: rpc.ProtocolJSONL}
+
+			pids := make([]string, 2)
+			for i := range pids {
+				resp := &rpc.SecretResponse{}
+				if err := CallContext(context.Background(), spec, &rpc.SecretRequest{Ref: "a"}, resp); err != nil {
+					t.Fatalf("call %d returned error: %v", i+1, err)
+				}
+				pids[i] = resp.GetMetadata()["pid"]
+			}
+			if pids[0] == "" {
+				t.Fatal("plugin did not report its pid")
+			}
+			if reused := pids[0] == pids[1]; reused != tt.reused {
+				t.Fatalf("calls served by processes %v, want reused = %v", pids, tt.reused)
+			}
+		})
+	}
+}
+
+func TestCallContextRunsPluginsConcurrently(t *testing.T) {
+	t.Cleanup(func() { _ = Shutdown() })
+
+	// The slow plugin holds its process until released; a call to another
+	// plugin must not wait for it.
+	release := filepath.Join(t.TempDir(), "release")
+	slow := `#!/bin/sh
+echo '{"protocol_version":1,"kind":"provider"}'
+while IFS= read -r line; do
+	while [ ! -e ` + release + ` ]; do sleep 0.05; done
+	echo '{"value":"YQ=="}'
+done
+`
+	slowPath := filepath.Join(t.TempDir(), "slow")
+	if err := os.WriteFile(slowPath, []byte(slow), 0o755); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		spec := Spec{Name: "slow", Kind: "provider", Path: slowPath, Protocol: rpc.ProtocolJSONL}
+		done <- CallContext(context.Background(), spec, &rpc.SecretRequest{Ref: "a"}, &rpc.SecretResponse{})
+	}()
+
+	fast := Spec{Name: "fast", Kind: "provider", Path: pidPlugin(t, ""), Protocol: rpc.ProtocolJSONL}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := CallContext(ctx, fast, &rpc.SecretRequest{Ref: "a"}, &rpc.SecretResponse{}); err != nil {
+		t.Fatalf("fast plugin call returned error: %v", err)
+	}
+
+	if err := os.WriteFile(release, nil, 0o644); err != nil {
+		t.Fatalf("release slow plugin: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("slow plugin call returned error: %v", err)
+	}
+}
+
+func TestStartProcessTimesOutWithoutHandshake(t *testing.T) {
+	defer func(d time.Duration) { handshakeTimeout = d }(handshakeTimeout)
+	handshakeTimeout = 200 * time.Millisecond
+
+	// A plugin built before the handshake existed waits for requests silently.
+	path := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nwhile read -r line; do :; done\n"), 0o755); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := StartProcess(context.Background(), Spec{Name: "legacy", Kind: "provider", Path: path})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "did not complete handshake") {
+			t.Fatalf("expected handshake timeout, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("StartProcess did not return")
+	}
+}
+
+func TestStartProcessRejectsProtocolMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugin")
+	script := "#!/bin/sh\necho '{\"protocol_version\":2,\"kind\":\"provider\"}'\ncat >/dev/null\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+
+	_, err := StartProcess(context.Background(), Spec{Name: "future", Kind: "provider", Path: path, Protocol: rpc.ProtocolJSONL})
+	if err == nil || !strings.Contains(err.Error(), "unsupported protocol version 2") {
+		t.Fatalf("expected protocol mismatch, got %v", err)
+	}
+}
+
+func TestStartProcessPassesMagicCookie(t *testing.T) {
+	// The plugin only announces itself when launched with the host's cookie.
+	path := filepath.Join(t.TempDir(), "plugin")
+	script := `#!/bin/sh
+[ "$` + rpc.HandshakeCookieKey + `" = "` + rpc.HandshakeCookieValue + `" ] || exit 1
+echo '{"protocol_version":1,"kind":"provider"}'
+cat >/dev/null
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+
+	p, err := StartProcess(context.Background(), Spec{Name: "cookie", Kind: "provider", Path: path, Protocol: rpc.ProtocolJSONL, EnvAllow: []string{}})
+	if err != nil {
+		t.Fatalf("StartProcess returned error: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+}
+
+func TestCallContextAbandonsRequestOnCancel(t *testing.T) {
+	// The plugin never answers and exits once its stdin is closed.
+	path := filepath.Join(t.TempDir(), "plugin")
+	script := "#!/bin/sh\necho '{\"protocol_version\":1,\"kind\":\"provider\"}'\ncat >/dev/null\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+	t.Cleanup(func() { _ = Shutdown() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		var resp rpc.SecretResponse
+		done <- CallContext(ctx, Spec{Name: "stuck", Kind: "provider", Path: path, Protocol: rpc.ProtocolJSONL}, &rpc.SecretRequest{Ref: "a"}, &resp)
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("CallContext did not return after its context ended")
+	}
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// remotePlugin talks to a long-lived plugin served with provider.Serve or exporter.Serve.
+type remotePlugin struct {
+	base     string
+	protocol string
+	http     *http.Client
+}
+
+var (
+	remoteMu sync.Mutex
+	remotes  = make(map[string]*remotePlugin)
+)
+
+// lookupRemote reports whether spec points at a unix:// or http(s):// endpoint and
+// returns a client for it. Clients are cached so connections are reused.
+func lookupRemote(spec Spec) (*remotePlugin, bool, error) {
+	if !rpc.IsRemote(spec.Path) {
+		return nil, false, nil
+	}
+
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+
+	key := spec.Protocol + " " + spec.Path
+	if r, ok := remotes[key]; ok {
+		return r, true, nil
+	}
+
+	r, err := newRemotePlugin(spec)
+	if err != nil {
+		return nil, true, err
+	}
+	remotes[key] = r
+	return r, true, nil
+}
+
+func newRemotePlugin(spec Spec) (*remotePlugin, error) {
+	u, err := url.Parse(spec.Path)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", spec.Path, err)
+	}
+	if _, err := rpc.CodecFor(spec.Protocol); err != nil {
+		return nil, err
+	}
+
+	r := &remotePlugin{protocol: spec.Protocol}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		r.base = "http://sfx-plugin"
+		r.http = &http.Client{Transport: transport}
+	default:
+		r.base = strings.TrimSuffix(u.String(), "/")
+		r.http = &http.Client{}
+	}
+	return r, nil
+}
+
+// handshake fetches and checks the plugin handshake.
+func (r *remotePlugin) handshake(ctx context.Context, spec Spec) (*rpc.Handshake, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.base+rpc.HandshakePath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", rpc.ContentType(r.protocol))
+
+	body, err := r.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", spec.Path, err)
+	}
+	defer body.Close()
+
+	codec, _ := rpc.CodecFor(r.protocol)
+	hs, err := rpc.ReadHandshake(codec, body)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", spec.Path, err)
+	}
+	if spec.Kind != "" && hs.GetKind() != "" && hs.GetKind() != spec.Kind {
+		return nil, fmt.Errorf("plugin %s: configured as %s but reports kind %q", spec.Path, spec.Kind, hs.GetKind())
+	}
+	return hs, nil
+}
+
+// call performs a single request/response exchange.
+func (r *remotePlugin) call(ctx context.Context, spec Spec, in proto.Message, out proto.Message) error {
+	codec, _ := rpc.CodecFor(r.protocol)
+
+	var buf bytes.Buffer
+	if err := codec.WriteMessage(&buf, in); err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.base+rpc.CallPath, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", rpc.ContentType(r.protocol))
+
+	body, err := r.do(req)
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", spec.Path, err)
+	}
+	defer body.Close()
+
+	if err := codec.ReadMessage(body, out); err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	return nil
+}
+
+func (r *remotePlugin) do(req *http.Request) (io.ReadCloser, error) {
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+	"github.com/fr0stylo/sfx/provider"
+)
+
+func serveProvider(t *testing.T, network, addr string) net.Listener {
+	t.Helper()
+
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	h := provider.HandlerFunc(func(_ context.Context, req provider.Request) (provider.Response, error) {
+		return provider.Response{Value: []byte("remote-" + req.Ref)}, nil
+	})
+	go func() { _ = provider.Serve(l, h, provider.WithRefFormat("<name>")) }()
+
+	return l
+}
+
+func TestCallContextReachesRemotePlugins(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "provider.sock")
+	serveProvider(t, "unix", socket)
+	tcp := serveProvider(t, "tcp", "127.0.0.1:0")
+
+	for _, spec := range []Spec{
+		{Name: "unix", Kind: "provider", Path: "unix://" + socket},
+		{Name: "http", Kind: "provider", Path: "http://" + tcp.Addr().String()},
+		{Name: "jsonl", Kind: "provider", Path: "http://" + tcp.Addr().String(), Protocol: rpc.ProtocolJSONL},
+	} {
+		t.Run(spec.Name, func(t *testing.T) {
+			hs, err := Describe(context.Background(), spec)
+			if err != nil {
+				t.Fatalf("Describe returned error: %v", err)
+			}
+			if hs.GetRefFormat() != "<name>" {
+				t.Fatalf("ref format = %q, want %q", hs.GetRefFormat(), "<name>")
+			}
+
+			var resp rpc.SecretResponse
+			if err := CallContext(context.Background(), spec, &rpc.SecretRequest{Ref: "db"}, &resp); err != nil {
+				t.Fatalf("CallContext returned error: %v", err)
+			}
+			if got := string(resp.GetValue()); got != "remote-db" {
+				t.Fatalf("value = %q, want %q", got, "remote-db")
+			}
+		})
+	}
+}
+
+func TestDescribeRejectsRemoteKindMismatch(t *testing.T) {
+	tcp := serveProvider(t, "tcp", "127.0.0.1:0")
+
+	spec := Spec{Name: "env", Kind: "exporter", Path: "http://" + tcp.Addr().String()}
+	if _, err := Describe(context.Background(), spec); err == nil {
+		t.Fatal("expected kind mismatch error")
+	}
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrNotFound is returned by Resolve when no binary exists for a plugin.
+var ErrNotFound = errors.New("not found")
+
+// SearchPathEnv lists additional plugin directories, separated by os.PathListSeparator.
+const SearchPathEnv = "SFX_PLUGIN_PATH"
+
+// BinaryName returns the conventional executable name for a plugin, e.g. sfx-provider-vault.
+func BinaryName(kind, name string) string {
+	bin := "sfx-" + kind + "-" + name
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	return bin
+}
+
+// SearchDirs returns the directories searched for plugin binaries, in priority order:
+// $SFX_PLUGIN_PATH, the directory holding the sfx executable and the user plugin directory.
+// $PATH is consulted last by Resolve.
+func SearchDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(SearchPathEnv)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		dirs = append(dirs, filepath.Dir(exe))
+	}
+
+	if dir := UserPluginDir(); dir != "" {
+		dirs = append(dirs, dir)
+	}
+
+	return dirs
+}
+
+// UserPluginDir returns the per-user plugin directory (e.g. ~/.config/sfx/plugins).
+func UserPluginDir() string {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(base, "sfx", "plugins")
+}
+
+// Resolve locates the binary for the named plugin of the given kind ("provider" or "exporter").
+// Each search directory is checked for sfx-<kind>-<name> and <kind>s/<name>, the latter
+// matching the layout produced by `make build`.
+func Resolve(kind, name string) (string, error) {
+	bin := BinaryName(kind, name)
+	dirs := SearchDirs()
+
+	for _, dir := range dirs {
+		for _, candidate := range []string{
+			filepath.Join(dir, bin),
+			filepath.Join(dir, kind+"s", name),
+		} {
+			if isExecutable(candidate) {
+				return candidate, nil
+			}
+		}
+	}
+
+	if path, err := exec.LookPath(bin); err == nil {
+		return path, nil
+	}
+
+	return "", fmt.Errorf("%s %q %w: no %s in %s or $PATH", kind, name, ErrNotFound, bin, strings.Join(dirs, string(os.PathListSeparator)))
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return info.Mode().Perm()&0o111 != 0
+}
+
+// CheckExecutable reports why the plugin at path cannot be executed, if at all.
+// WebAssembly modules only need to be regular files.
+func CheckExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if isWasm(path) && info.Mode().IsRegular() {
+		return nil
+	}
+	if !isExecutable(path) {
+		return fmt.Errorf("%s: %w (mode %s)", path, errNotExecutable, info.Mode())
+	}
+	return nil
+}
+
+var errNotExecutable = errors.New("not an executable file")
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeExecutable(t *testing.T, path string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatalf("write executable: %v", err)
+	}
+}
+
+func TestResolvePrefersSearchPathOrder(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	writeExecutable(t, filepath.Join(second, BinaryName("provider", "vault")))
+	writeExecutable(t, filepath.Join(first, "providers", "vault"))
+
+	t.Setenv(SearchPathEnv, first+string(os.PathListSeparator)+second)
+	t.Setenv("PATH", "")
+
+	got, err := Resolve("provider", "vault")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if want := filepath.Join(first, "providers", "vault"); got != want {
+		t.Fatalf("Resolve = %q, want %q", got, want)
+	}
+}
+
+func TestResolveFallsBackToPATH(t *testing.T) {
+	dir := t.TempDir()
+	writeExecutable(t, filepath.Join(dir, BinaryName("exporter", "env")))
+
+	t.Setenv(SearchPathEnv, "")
+	t.Setenv("PATH", dir)
+
+	got, err := Resolve("exporter", "env")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if want := filepath.Join(dir, BinaryName("exporter", "env")); got != want {
+		t.Fatalf("Resolve = %q, want %q", got, want)
+	}
+}
+
+func TestResolveSkipsNonExecutable(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, BinaryName("provider", "file")), nil, 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	t.Setenv(SearchPathEnv, dir)
+	t.Setenv("PATH", "")
+
+	_, err := Resolve("provider", "file")
+	if err == nil {
+		t.Fatal("Resolve returned nil error for non-executable plugin")
+	}
+	if !strings.Contains(err.Error(), "sfx-provider-file") {
+		t.Fatalf("error should name the binary, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// sandboxEnv carries encoded Limits from the host to the sandbox shim.
+const sandboxEnv = "SFX_PLUGIN_SANDBOX"
+
+// Limits are resource limits applied to a plugin process. They are enforced on
+// Linux only; zero values leave the corresponding limit untouched.
+type Limits struct {
+	// CPU caps the total CPU time of the process (RLIMIT_CPU), across all requests it serves.
+	CPU time.Duration
+	// AddressSpace caps the virtual memory of the process in bytes (RLIMIT_AS).
+	AddressSpace uint64
+	// OpenFiles caps the number of open file descriptors (RLIMIT_NOFILE).
+	OpenFiles uint64
+	// NoNewPrivs stops the plugin from gaining privileges through setuid binaries or file capabilities.
+	NoNewPrivs bool
+}
+
+// IsZero reports whether no limit is set.
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+func (l Limits) encode() string {
+	var parts []string
+	if l.CPU > 0 {
+		parts = append(parts, "cpu="+strconv.FormatUint(cpuSeconds(l.CPU), 10))
+	}
+	if l.AddressSpace > 0 {
+		parts = append(parts, "as="+strconv.FormatUint(l.AddressSpace, 10))
+	}
+	if l.OpenFiles > 0 {
+		parts = append(parts, "nofile="+strconv.FormatUint(l.OpenFiles, 10))
+	}
+	if l.NoNewPrivs {
+		parts = append(parts, "nnp=1")
+	}
+	return strings.Join(parts, ";")
+}
+
+func decodeLimits(s string) (Limits, error) {
+	var l Limits
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, raw, _ := strings.Cut(part, "=")
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return Limits{}, fmt.Errorf("sandbox: invalid %s limit %q", key, raw)
+		}
+		switch key {
+		case "cpu":
+			l.CPU = time.Duration(n) * time.Second
+		case "as":
+			l.AddressSpace = n
+		case "nofile":
+			l.OpenFiles = n
+		case "nnp":
+			l.NoNewPrivs = n != 0
+		default:
+			return Limits{}, fmt.Errorf("sandbox: unknown limit %q", key)
+		}
+	}
+	return l, nil
+}
+
+// cpuSeconds rounds d up to whole seconds, the granularity of RLIMIT_CPU.
+func cpuSeconds(d time.Duration) uint64 {
+	return uint64((d + time.Second - 1) / time.Second)
+}
+
+// pluginEnv builds the environment for the native plugin described by spec.
+// When an allowlist is configured only matching host variables are passed
+// through; EnvSet entries and the handshake variables are always added.
+func pluginEnv(spec Spec) []string {
+	env := os.Environ()
+	if spec.EnvAllow != nil {
+		env = filterEnv(env, spec.EnvAllow)
+	}
+	return withPluginVars(env, spec)
+}
+
+// wasmEnv builds the environment for a WebAssembly plugin. Unlike pluginEnv it
+// starts empty: only allowlisted host variables are passed through, so a module
+// without env.allow sees no credentials from the host.
+func wasmEnv(spec Spec) []string {
+	return withPluginVars(filterEnv(os.Environ(), spec.EnvAllow), spec)
+}
+
+// withPluginVars appends the EnvSet entries and the handshake variables to env.
+func withPluginVars(env []string, spec Spec) []string {
+	env = append(env, spec.EnvSet...)
+
+	env = append(env,
+		rpc.HandshakeCookieKey+"="+rpc.HandshakeCookieValue,
+		rpc.LogLevelKey+"="+hostLogLevel().String(),
+	)
+	if spec.Protocol != "" {
+		env = append(env, rpc.ProtocolKey+"="+spec.Protocol)
+	}
+	return env
+}
+
+// filterEnv keeps the variables whose name matches one of the glob patterns.
+func filterEnv(env []string, allow []string) []string {
+	var out []string
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		for _, pattern := range allow {
+			if ok, _ := path.Match(pattern, name); ok {
+				out = append(out, kv)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// pluginPath returns the binary path to execute. Relative paths are resolved
+// against the host's working directory so that a configured Dir does not change
+// which binary runs.
+func pluginPath(spec Spec) (string, error) {
+	if spec.Dir == "" || filepath.IsAbs(spec.Path) {
+		return spec.Path, nil
+	}
+	return filepath.Abs(spec.Path)
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// command prepares the plugin process, which runs exe and sees name as its
+// argv[0]. When limits are configured, or exe is a pinned descriptor, the sfx
+// binary re-executes itself as a shim that applies the limits, releases the
+// descriptor and then execs the plugin, so this is in place before any plugin
+// code runs.
+func command(ctx context.Context, spec Spec, exe, name string, env []string) (*exec.Cmd, error) {
+	if spec.Limits.IsZero() && exe == name {
+		cmd := exec.CommandContext(ctx, exe)
+		cmd.Env = env
+		return cmd, nil
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locate sfx executable for sandbox: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, self, exe, name)
+	cmd.Env = append(env, sandboxEnv+"="+spec.Limits.encode())
+	return cmd, nil
+}
+
+// pinnedFD is the descriptor a pinned binary is passed on.
+const pinnedFD = 3
+
+// pinnedExec returns the path that starts the verified binary f and the files
+// the child inherits. f is passed as descriptor 3 and executed through
+// /proc/self/fd, so the file that was hashed is the file that runs even if its
+// path is replaced in between. Without /proc the path is executed instead.
+func pinnedExec(f *os.File) (string, []*os.File) {
+	if _, err := os.Stat("/proc/self/fd"); err != nil {
+		return f.Name(), nil
+	}
+	return fmt.Sprintf("/proc/self/fd/%d", pinnedFD), []*os.File{f}
+}
+
+// SandboxMain must be called first thing in main. When the process was started
+// as a plugin sandbox shim it applies the requested limits and replaces itself
+// with the plugin binary; otherwise it returns immediately.
+func SandboxMain() {
+	raw, ok := os.LookupEnv(sandboxEnv)
+	if !ok {
+		return
+	}
+
+	if err := execSandboxed(raw); err != nil {
+		fmt.Fprintf(os.Stderr, "sfx sandbox: %v\n", err)
+		os.Exit(127)
+	}
+}
+
+func execSandboxed(raw string) error {
+	if len(os.Args) < 2 {
+		return fmt.Errorf("missing plugin path")
+	}
+
+	limits, err := decodeLimits(raw)
+	if err != nil {
+		return err
+	}
+	if err := os.Unsetenv(sandboxEnv); err != nil {
+		return err
+	}
+
+	if limits.CPU > 0 {
+		if err := setrlimit(unix.RLIMIT_CPU, cpuSeconds(limits.CPU)); err != nil {
+			return fmt.Errorf("limit cpu: %w", err)
+		}
+	}
+	if limits.AddressSpace > 0 {
+		if err := setrlimit(unix.RLIMIT_AS, limits.AddressSpace); err != nil {
+			return fmt.Errorf("limit address space: %w", err)
+		}
+	}
+	if limits.OpenFiles > 0 {
+		if err := setrlimit(unix.RLIMIT_NOFILE, limits.OpenFiles); err != nil {
+			return fmt.Errorf("limit open files: %w", err)
+		}
+	}
+	if limits.NoNewPrivs {
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return fmt.Errorf("set no_new_privs: %w", err)
+		}
+	}
+
+	path, name := os.Args[1], os.Args[1]
+	if len(os.Args) > 2 {
+		name = os.Args[2]
+	}
+	if path == fmt.Sprintf("/proc/self/fd/%d", pinnedFD) {
+		releasePinned()
+	}
+	return syscall.Exec(path, []string{name}, os.Environ())
+}
+
+// releasePinned marks the descriptor of a pinned binary close-on-exec, so the
+// kernel still starts the binary from it but the plugin does not inherit it.
+// Scripts keep the descriptor: their interpreter opens the script through the
+// same /proc/self/fd path once the exec is done.
+func releasePinned() {
+	magic := make([]byte, 2)
+	if n, _ := unix.Pread(pinnedFD, magic, 0); n == len(magic) && string(magic) == "#!" {
+		return
+	}
+	unix.CloseOnExec(pinnedFD)
+}
+
+func setrlimit(resource int, limit uint64) error {
+	return unix.Setrlimit(resource, &unix.Rlimit{Cur: limit, Max: limit})
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// argvPluginEnv makes the test binary serve as a provider that answers every
+// request with its argv[0] and whether it inherited the pinned descriptor.
+const argvPluginEnv = "SFX_TEST_ARGV_PLUGIN"
+
+func init() {
+	// The test binary stands in for sfx as the sandbox shim, and for the plugin.
+	SandboxMain()
+	if os.Getenv(argvPluginEnv) == "" {
+		return
+	}
+
+	// The runtime may reuse descriptor 3 for files of its own, so compare it
+	// with the binary rather than checking whether it is open.
+	fd := "closed"
+	target, _ := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", pinnedFD))
+	if exe, err := os.Executable(); err == nil && target == exe {
+		fd = "inherited"
+	}
+
+	c, _ := rpc.CodecFor(rpc.ProtocolJSONL)
+	_ = c.WriteMessage(os.Stdout, &rpc.Handshake{ProtocolVersion: rpc.ProtocolVersion, Kind: "provider"})
+	in := bufio.NewReader(os.Stdin)
+	for {
+		if err := c.ReadMessage(in, &rpc.SecretRequest{}); err != nil {
+			os.Exit(0)
+		}
+		_ = c.WriteMessage(os.Stdout, &rpc.SecretResponse{Value: []byte(os.Args[0] + " fd3=" + fd)})
+	}
+}
+
+func TestPinnedPluginSeesItsPathAndNotTheDescriptor(t *testing.T) {
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatalf("locate test binary: %v", err)
+	}
+	sum, err := FileSHA256(self)
+	if err != nil {
+		t.Fatalf("FileSHA256 returned error: %v", err)
+	}
+
+	for name, limits := range map[string]Limits{"pinned": {}, "pinned with limits": {OpenFiles: 256}} {
+		t.Run(name, func(t *testing.T) {
+			spec := Spec{Name: "argv", Kind: "provider", Path: self, Protocol: rpc.ProtocolJSONL, SHA256: sum, Limits: limits, EnvSet: []string{argvPluginEnv + "=1"}}
+			p, err := StartProcess(context.Background(), spec)
+			if err != nil {
+				t.Fatalf("StartProcess returned error: %v", err)
+			}
+			defer p.Close()
+
+			resp := &rpc.SecretResponse{}
+			if err := p.Call(context.Background(), &rpc.SecretRequest{Ref: "a"}, resp); err != nil {
+				t.Fatalf("Call returned error: %v", err)
+			}
+			if want := self + " fd3=closed"; string(resp.GetValue()) != want {
+				t.Fatalf("plugin reported %q, want %q", resp.GetValue(), want)
+			}
+		})
+	}
+}
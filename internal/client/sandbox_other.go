@@ -0,0 +1,35 @@
+//go:build !linux
+
+package client
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+)
+
+// command prepares the plugin process. Resource limits are only enforced on
+// Linux; elsewhere they are reported and ignored. The process runs exe and
+// sees name as its argv[0].
+func command(ctx context.Context, spec Spec, exe, name string, env []string) (*exec.Cmd, error) {
+	if !spec.Limits.IsZero() {
+		slog.Warn("plugin resource limits are only enforced on Linux", "plugin", spec.Name, "kind", spec.Kind)
+	}
+
+	cmd := exec.CommandContext(ctx, exe)
+	cmd.Args[0] = name
+	cmd.Env = env
+	return cmd, nil
+}
+
+// pinnedExec returns the path that starts the verified binary f. Only Linux can
+// execute an open file, so elsewhere the path is executed and the binary could
+// be replaced between the pin check and the start.
+func pinnedExec(f *os.File) (string, []*os.File) {
+	return f.Name(), nil
+}
+
+// SandboxMain must be called first thing in main. Plugin sandboxing is only
+// implemented on Linux, so it does nothing here.
+func SandboxMain() {}
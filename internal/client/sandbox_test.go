@@ -0,0 +1,53 @@
+package client
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+func TestPluginEnvAppliesAllowlistAndOverrides(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "https://vault.example.com")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "hunter2")
+
+	env := pluginEnv(Spec{
+		EnvAllow: []string{"VAULT_*"},
+		EnvSet:   []string{"EXTRA=1"},
+	})
+
+	for _, want := range []string{
+		"VAULT_ADDR=https://vault.example.com",
+		"EXTRA=1",
+		rpc.HandshakeCookieKey + "=" + rpc.HandshakeCookieValue,
+	} {
+		if !slices.Contains(env, want) {
+			t.Fatalf("env missing %q: %v", want, env)
+		}
+	}
+	if slices.Contains(env, "AWS_SECRET_ACCESS_KEY=hunter2") {
+		t.Fatalf("env leaked a variable outside the allowlist: %v", env)
+	}
+}
+
+func TestPluginEnvInheritsWithoutAllowlist(t *testing.T) {
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "hunter2")
+
+	if env := pluginEnv(Spec{}); !slices.Contains(env, "AWS_SECRET_ACCESS_KEY=hunter2") {
+		t.Fatalf("expected the host environment to be inherited: %v", env)
+	}
+}
+
+func TestLimitsRoundTrip(t *testing.T) {
+	want := Limits{CPU: 1500 * time.Millisecond, AddressSpace: 512 << 20, OpenFiles: 64, NoNewPrivs: true}
+
+	got, err := decodeLimits(want.encode())
+	if err != nil {
+		t.Fatalf("decodeLimits returned error: %v", err)
+	}
+	want.CPU = 2 * time.Second // RLIMIT_CPU has one-second granularity
+	if got != want {
+		t.Fatalf("decodeLimits = %+v, want %+v", got, want)
+	}
+}
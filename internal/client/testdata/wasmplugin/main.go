@@ -0,0 +1,52 @@
+// Command wasmplugin is a test plugin built for GOOS=wasip1 by wasm_test.go.
+// It runs as an exporter when SFX_TEST_KIND=exporter and as a provider
+// otherwise. The provider resolves env:<NAME> to the variable and
+// file:<path> to the file's content, so tests can observe what the sandbox
+// exposes.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/provider"
+)
+
+func main() {
+	if os.Getenv("SFX_TEST_KIND") == "exporter" {
+		exporter.Run(exporter.HandlerFunc(export))
+		return
+	}
+	provider.Run(provider.HandlerFunc(resolve))
+}
+
+func resolve(_ context.Context, req provider.Request) (provider.Response, error) {
+	scheme, rest, _ := strings.Cut(req.Ref, ":")
+	switch scheme {
+	case "env":
+		return provider.Response{Value: []byte(os.Getenv(rest))}, nil
+	case "file":
+		b, err := os.ReadFile(rest)
+		return provider.Response{Value: b}, err
+	default:
+		return provider.Response{}, fmt.Errorf("unsupported ref %q", req.Ref)
+	}
+}
+
+func export(req exporter.Request) (exporter.Response, error) {
+	keys := make([]string, 0, len(req.Values))
+	for k := range req.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", k, req.Values[k])
+	}
+	return exporter.Response{Payload: []byte(b.String())}, nil
+}
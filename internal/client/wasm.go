@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// wasmPageSize is the size of a WebAssembly linear memory page.
+const wasmPageSize = 64 << 10
+
+// Mount exposes a host directory to a WebAssembly plugin. WebAssembly plugins
+// see no host files except through mounts.
+type Mount struct {
+	Host     string
+	Guest    string
+	ReadOnly bool
+}
+
+// compilationCache keeps compiled modules on disk so repeated runs skip compilation.
+var compilationCache = sync.OnceValue(func() wazero.CompilationCache {
+	if dir, err := os.UserCacheDir(); err == nil {
+		if cache, err := wazero.NewCompilationCacheWithDir(filepath.Join(dir, "sfx", "wasm")); err == nil {
+			return cache
+		}
+	}
+	return wazero.NewCompilationCache()
+})
+
+// pipeWriter drops empty writes, which an io.Pipe would otherwise block on until
+// the host reads; a zero-length payload is never read.
+type pipeWriter struct{ *io.PipeWriter }
+
+func (w pipeWriter) Write(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	return w.PipeWriter.Write(b)
+}
+
+func isWasm(path string) bool {
+	return strings.HasSuffix(path, ".wasm")
+}
+
+// launchWasm runs a WASI module in the embedded runtime, wiring its stdio to the
+// same pipe protocol native plugins use. The module sees only the configured
+// mounts and the environment built by wasmEnv. It is never granted network
+// access: WASI preview 1 has no sockets and no host functions are added.
+func launchWasm(ctx context.Context, spec Spec) (pipes, error) {
+	if as := spec.Limits.AddressSpace; as > 0 && as < wasmPageSize {
+		return pipes{}, fmt.Errorf("plugin %s: address space limit of %d bytes is below one WebAssembly page (64 KiB)", spec.Path, as)
+	}
+
+	bin, err := os.ReadFile(spec.Path)
+	if err != nil {
+		return pipes{}, err
+	}
+	// The module is compiled from bin, so hashing it leaves no window for the
+	// file to change.
+	if spec.SHA256 != "" {
+		sum := sha256.Sum256(bin)
+		if err := matchPin(spec.Path, spec.SHA256, sum[:]); err != nil {
+			return pipes{}, err
+		}
+	}
+
+	cfg := wazero.NewRuntimeConfig().
+		WithCompilationCache(compilationCache()).
+		WithCloseOnContextDone(true)
+	if spec.Limits.AddressSpace > 0 {
+		cfg = cfg.WithMemoryLimitPages(uint32(min(spec.Limits.AddressSpace/wasmPageSize, 1<<16)))
+	}
+	runtime := wazero.NewRuntimeWithConfig(ctx, cfg)
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		_ = runtime.Close(ctx)
+		return pipes{}, fmt.Errorf("plugin %s: %w", spec.Path, err)
+	}
+	compiled, err := runtime.CompileModule(ctx, bin)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return pipes{}, fmt.Errorf("plugin %s: compile: %w", spec.Path, err)
+	}
+
+	fsCfg := wazero.NewFSConfig()
+	for _, m := range spec.Mounts {
+		if m.ReadOnly {
+			fsCfg = fsCfg.WithReadOnlyDirMount(m.Host, m.Guest)
+		} else {
+			fsCfg = fsCfg.WithDirMount(m.Host, m.Guest)
+		}
+	}
+
+	stdinR, stdinW := io.Pipe()
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
+
+	modCfg := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(filepath.Base(spec.Path)).
+		WithStdin(stdinR).
+		WithStdout(pipeWriter{stdoutW}).
+		WithStderr(stderrW).
+		WithFSConfig(fsCfg).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader)
+	env := wasmEnv(spec)
+	if spec.Dir != "" {
+		env = append(env, "PWD="+spec.Dir)
+	}
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			modCfg = modCfg.WithEnv(k, v)
+		}
+	}
+
+	// Cancelling runCtx closes the module, which is how kill stops it.
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		_, err := runtime.InstantiateModule(runCtx, compiled, modCfg)
+		var exit *sys.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == 0 {
+			err = nil
+		}
+		_ = stdoutW.CloseWithError(io.EOF)
+		_ = stderrW.CloseWithError(io.EOF)
+		_ = stdinR.Close()
+		done <- err
+	}()
+
+	wait := func() error {
+		err := <-done
+		cancel()
+		_ = runtime.Close(context.Background())
+		if err != nil {
+			return fmt.Errorf("plugin %s: %w", spec.Path, err)
+		}
+		return nil
+	}
+
+	// Modules cannot be signalled, so an abandoned request runs to completion.
+	return pipes{stdin: stdinW, stdout: stdoutR, stderr: stderrR, wait: wait, interrupt: func() {}, kill: cancel}, nil
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// wasmPlugin builds testdata/wasmplugin for wasip1.
+func wasmPlugin(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds a WebAssembly module")
+	}
+	gotool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	path := filepath.Join(t.TempDir(), "plugin.wasm")
+	cmd := exec.Command(gotool, "build", "-o", path, "./testdata/wasmplugin")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("build wasm plugin: %v\n%s", err, out)
+	}
+	return path
+}
+
+// callWasm runs one request through a fresh instance of the module.
+func callWasm(t *testing.T, spec Spec, req, resp proto.Message) {
+	t.Helper()
+	if err := Call(context.Background(), spec, req, resp); err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+}
+
+func TestCheckExecutableAcceptsWasmModule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugin.wasm")
+	if err := os.WriteFile(path, []byte("\x00asm"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if err := CheckExecutable(path); err != nil {
+		t.Fatalf("CheckExecutable returned error: %v", err)
+	}
+}
+
+func TestStartProcessRejectsInvalidWasm(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugin.wasm")
+	if err := os.WriteFile(path, []byte("not wasm"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	_, err := StartProcess(context.Background(), Spec{Name: "broken", Kind: "provider", Path: path})
+	if err == nil || !strings.Contains(err.Error(), "compile") {
+		t.Fatalf("expected compile error, got %v", err)
+	}
+}
+
+func TestWasmProviderSandbox(t *testing.T) {
+	path := wasmPlugin(t)
+	t.Setenv("SFX_WASM_HOST_SECRET", "host-only")
+	t.Setenv("SFX_WASM_ALLOWED", "allowed")
+
+	data := t.TempDir()
+	if err := os.WriteFile(filepath.Join(data, "secret.txt"), []byte("mounted"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	base := Spec{
+		Name:   "sandbox",
+		Kind:   "provider",
+		Path:   path,
+		Mounts: []Mount{{Host: data, Guest: "/data", ReadOnly: true}},
+	}
+	allowed := base
+	allowed.EnvAllow = []string{"SFX_WASM_ALLOW*"}
+	allowed.EnvSet = []string{"SFX_WASM_SET=set"}
+
+	tests := []struct {
+		name    string
+		spec    Spec
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{name: "host env hidden by default", spec: base, ref: "env:SFX_WASM_HOST_SECRET", want: ""},
+		{name: "allowlisted env passed", spec: allowed, ref: "env:SFX_WASM_ALLOWED", want: "allowed"},
+		{name: "unlisted env hidden", spec: allowed, ref: "env:SFX_WASM_HOST_SECRET", want: ""},
+		{name: "set env passed", spec: allowed, ref: "env:SFX_WASM_SET", want: "set"},
+		{name: "mount readable", spec: base, ref: "file:/data/secret.txt", want: "mounted"},
+		{name: "host files hidden", spec: base, ref: "file:" + filepath.Join(data, "secret.txt"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &rpc.SecretResponse{}
+			callWasm(t, tt.spec, &rpc.SecretRequest{Ref: tt.ref}, resp)
+			if tt.wantErr {
+				if resp.GetError() == "" {
+					t.Fatalf("expected an error, got value %q", resp.GetValue())
+				}
+				return
+			}
+			if resp.GetError() != "" {
+				t.Fatalf("plugin returned error: %s", resp.GetError())
+			}
+			if string(resp.GetValue()) != tt.want {
+				t.Fatalf("value = %q, want %q", resp.GetValue(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWasmExporterRoundTrip(t *testing.T) {
+	spec := Spec{Name: "sandbox", Kind: "exporter", Path: wasmPlugin(t), EnvSet: []string{"SFX_TEST_KIND=exporter"}}
+
+	resp := &rpc.ExportResponse{}
+	callWasm(t, spec, &rpc.ExportRequest{Values: map[string][]byte{"B": []byte("2"), "A": []byte("1")}}, resp)
+	if resp.GetError() != "" {
+		t.Fatalf("plugin returned error: %s", resp.GetError())
+	}
+	if got, want := string(resp.GetPayload()), "A=1\nB=2\n"; got != want {
+		t.Fatalf("payload = %q, want %q", got, want)
+	}
+}
+
+func TestStartProcessRejectsWasmMemoryBelowOnePage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugin.wasm")
+	if err := os.WriteFile(path, []byte("\x00asm"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	_, err := StartProcess(context.Background(), Spec{Name: "tiny", Kind: "provider", Path: path, Limits: Limits{AddressSpace: 32 << 10}})
+	if err == nil || !strings.Contains(err.Error(), "below one WebAssembly page") {
+		t.Fatalf("expected address space error, got %v", err)
+	}
+}
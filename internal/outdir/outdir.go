@@ -0,0 +1,221 @@
+// Package outdir writes the files returned by an exporter under a directory.
+package outdir
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// DefaultMode is used for files that do not ask for a mode. Exported files
+// usually hold secrets, so they are private to the user by default.
+const DefaultMode fs.FileMode = 0o600
+
+// dirMode is used for directories created for nested paths.
+const dirMode fs.FileMode = 0o700
+
+// StateFile records, inside the output directory, the files written by the
+// last call to Replace.
+const StateFile = ".sfx-files"
+
+// File is a file to write, with Path relative to the output directory and
+// slash-separated.
+type File struct {
+	Path    string
+	Content []byte
+	// Mode holds the permission bits; zero selects DefaultMode.
+	Mode fs.FileMode
+}
+
+// CheckPath reports whether p may be written under an output directory: it
+// must be a non-empty, slash-separated relative path that stays inside the
+// directory once cleaned.
+func CheckPath(p string) error {
+	if p == "" {
+		return errors.New("empty file path")
+	}
+	if path.IsAbs(p) || filepath.IsAbs(p) {
+		return fmt.Errorf("file path %q must be relative", p)
+	}
+	if !filepath.IsLocal(filepath.FromSlash(p)) {
+		return fmt.Errorf("file path %q escapes the output directory", p)
+	}
+	return nil
+}
+
+// Write writes files under dir, creating dir and any parent directories. All
+// paths are checked before anything is written, and the writes go through an
+// os.Root so symlinks inside dir cannot redirect them elsewhere. Each file is
+// first written to a temporary file next to it and then renamed into place,
+// so readers never see a partial file; no file is replaced unless every file
+// was written.
+func Write(dir string, files []File) (err error) {
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		if err := CheckPath(f.Path); err != nil {
+			return err
+		}
+		clean := path.Clean(f.Path)
+		if seen[clean] {
+			return fmt.Errorf("file path %q is returned more than once", f.Path)
+		}
+		seen[clean] = true
+	}
+
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return fmt.Errorf("open output directory: %w", err)
+	}
+	defer root.Close() //nolint:errcheck
+
+	temps := make([]string, 0, len(files))
+	defer func() {
+		if err != nil {
+			for _, tmp := range temps {
+				_ = root.Remove(tmp)
+			}
+		}
+	}()
+
+	for _, f := range files {
+		tmp, err := writeTemp(root, f)
+		if err != nil {
+			return fmt.Errorf("write %s: %w", f.Path, err)
+		}
+		temps = append(temps, tmp)
+	}
+
+	for i, f := range files {
+		if err := root.Rename(temps[i], filepath.FromSlash(path.Clean(f.Path))); err != nil {
+			temps = temps[i:]
+			return fmt.Errorf("write %s: %w", f.Path, err)
+		}
+	}
+	temps = nil
+	return nil
+}
+
+// Replace writes files under dir like Write, then removes the files that the
+// previous Replace into dir wrote and files no longer includes, along with
+// directories left empty by their removal. The written paths are recorded in
+// StateFile, so files that sfx did not write are never removed.
+func Replace(dir string, files []File) error {
+	keep := make(map[string]bool, len(files))
+	for _, f := range files {
+		if path.Clean(f.Path) == StateFile {
+			return fmt.Errorf("file path %q is reserved", f.Path)
+		}
+		keep[path.Clean(f.Path)] = true
+	}
+
+	if err := Write(dir, files); err != nil {
+		return err
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return fmt.Errorf("open output directory: %w", err)
+	}
+	defer root.Close() //nolint:errcheck
+
+	previous, err := readState(root)
+	if err != nil {
+		return err
+	}
+	for _, p := range previous {
+		if keep[p] || CheckPath(p) != nil {
+			continue
+		}
+		if err := root.Remove(filepath.FromSlash(p)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove %s: %w", p, err)
+		}
+		// Remove fails on directories that still hold files, which is the point.
+		for d := path.Dir(p); d != "."; d = path.Dir(d) {
+			if root.Remove(filepath.FromSlash(d)) != nil {
+				break
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(keep))
+	for _, f := range files {
+		paths = append(paths, path.Clean(f.Path))
+	}
+	state, err := json.Marshal(paths)
+	if err != nil {
+		return err
+	}
+	tmp, err := writeTemp(root, File{Path: StateFile, Content: state})
+	if err == nil {
+		err = root.Rename(tmp, StateFile)
+	}
+	if err != nil {
+		return fmt.Errorf("record written files: %w", err)
+	}
+	return nil
+}
+
+func readState(root *os.Root) ([]string, error) {
+	raw, err := root.ReadFile(StateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", StateFile, err)
+	}
+	var paths []string
+	if err := json.Unmarshal(raw, &paths); err != nil {
+		return nil, fmt.Errorf("read %s: %w", StateFile, err)
+	}
+	return paths, nil
+}
+
+func writeTemp(root *os.Root, f File) (string, error) {
+	name := filepath.FromSlash(path.Clean(f.Path))
+	if d := filepath.Dir(name); d != "." {
+		if err := root.MkdirAll(d, dirMode); err != nil {
+			return "", err
+		}
+	}
+
+	mode := f.Mode.Perm()
+	if mode == 0 {
+		mode = DefaultMode
+	}
+
+	var suffix [6]byte
+	if _, err := rand.Read(suffix[:]); err != nil {
+		return "", err
+	}
+	tmp := filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".sfx-"+hex.EncodeToString(suffix[:]))
+
+	out, err := root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return "", err
+	}
+	_, err = out.Write(f.Content)
+	if err == nil {
+		err = out.Sync()
+	}
+	// The umask may have narrowed the mode the file was created with.
+	if err == nil {
+		err = out.Chmod(mode)
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = root.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
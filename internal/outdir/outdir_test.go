@@ -0,0 +1,133 @@
+package outdir
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteCreatesFilesWithModes(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	err := Write(dir, []File{
+		{Path: "app.env", Content: []byte("A=1\n")},
+		{Path: "k8s/secret.yaml", Content: []byte("kind: Secret\n"), Mode: 0o640},
+	})
+	if err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	for name, want := range map[string]os.FileMode{"app.env": DefaultMode, "k8s/secret.yaml": 0o640} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("stat %s: %v", name, err)
+		}
+		if info.Mode().Perm() != want {
+			t.Fatalf("%s mode = %v, want %v", name, info.Mode().Perm(), want)
+		}
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "k8s", "secret.yaml"))
+	if err != nil || string(got) != "kind: Secret\n" {
+		t.Fatalf("read k8s/secret.yaml = (%q, %v)", got, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			t.Fatalf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestWriteRefusesPathsOutsideDir(t *testing.T) {
+	for _, p := range []string{"", "/etc/passwd", "../escape", "a/../../escape", "a/b/../../../c"} {
+		dir := t.TempDir()
+		err := Write(dir, []File{{Path: "ok", Content: []byte("x")}, {Path: p, Content: []byte("x")}})
+		if err == nil {
+			t.Fatalf("Write accepted path %q", p)
+		}
+		if _, statErr := os.Stat(filepath.Join(dir, "ok")); !os.IsNotExist(statErr) {
+			t.Fatalf("Write(%q) wrote other files before failing", p)
+		}
+	}
+}
+
+func TestWriteDoesNotFollowSymlinksOutOfDir(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	if err := Write(dir, []File{{Path: "link/secret", Content: []byte("x")}}); err == nil {
+		t.Fatal("Write followed a symlink out of the output directory")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "secret")); !os.IsNotExist(err) {
+		t.Fatalf("file written outside the output directory: %v", err)
+	}
+}
+
+func TestWriteRejectsDuplicatePaths(t *testing.T) {
+	err := Write(t.TempDir(), []File{{Path: "a/b"}, {Path: "a//b"}})
+	if err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Fatalf("Write error = %v, want duplicate path", err)
+	}
+}
+
+func TestReplaceRemovesFilesItNoLongerWrites(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "user.txt"), []byte("mine"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Replace(dir, []File{
+		{Path: "db/password", Content: []byte("p")},
+		{Path: "db/user", Content: []byte("u")},
+		{Path: "cache/url", Content: []byte("c")},
+	})
+	if err != nil {
+		t.Fatalf("Replace returned error: %v", err)
+	}
+	if err := Replace(dir, []File{{Path: "db/password", Content: []byte("p2")}}); err != nil {
+		t.Fatalf("Replace returned error: %v", err)
+	}
+
+	for _, gone := range []string{"db/user", "cache/url", "cache"} {
+		if _, err := os.Stat(filepath.Join(dir, gone)); !os.IsNotExist(err) {
+			t.Fatalf("%s still exists: %v", gone, err)
+		}
+	}
+	for name, want := range map[string]string{"db/password": "p2", "user.txt": "mine"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(got) != want {
+			t.Fatalf("read %s = (%q, %v), want %q", name, got, err, want)
+		}
+	}
+}
+
+func TestReplaceIgnoresStateOutsideDir(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "out")
+	victim := filepath.Join(parent, "victim")
+	if err := os.WriteFile(victim, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, StateFile), []byte(`["../victim"]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Replace(dir, nil); err != nil {
+		t.Fatalf("Replace returned error: %v", err)
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Fatalf("Replace removed a file outside the output directory: %v", err)
+	}
+	if err := Replace(dir, []File{{Path: StateFile}}); err == nil {
+		t.Fatal("Replace accepted the state file as an output")
+	}
+}
@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// ProtocolKey is the environment variable the host sets to select the wire protocol.
+	ProtocolKey = "SFX_PLUGIN_PROTOCOL"
+
+	// ProtocolProtobuf frames messages as varint length-delimited protobuf (the default).
+	ProtocolProtobuf = "protobuf"
+	// ProtocolJSONL sends each message as a single line of protobuf JSON with snake_case field names.
+	ProtocolJSONL = "jsonl"
+)
+
+// Codec reads and writes plugin messages on a stream.
+type Codec interface {
+	WriteMessage(w io.Writer, msg proto.Message) error
+	ReadMessage(r io.Reader, msg proto.Message) error
+}
+
+// CodecFor returns the codec for the named protocol; an empty name selects protobuf.
+func CodecFor(protocol string) (Codec, error) {
+	switch protocol {
+	case "", ProtocolProtobuf:
+		return delimitedCodec{}, nil
+	case ProtocolJSONL:
+		return jsonlCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown plugin protocol %q (want %q or %q)", protocol, ProtocolProtobuf, ProtocolJSONL)
+	}
+}
+
+// HostCodec returns the codec requested by the host through ProtocolKey.
+func HostCodec() (Codec, error) {
+	return CodecFor(os.Getenv(ProtocolKey))
+}
+
+type delimitedCodec struct{}
+
+func (delimitedCodec) WriteMessage(w io.Writer, msg proto.Message) error {
+	return WriteDelimited(w, msg)
+}
+
+func (delimitedCodec) ReadMessage(r io.Reader, msg proto.Message) error {
+	return ReadDelimited(r, msg)
+}
+
+type jsonlCodec struct{}
+
+var (
+	jsonMarshal   = protojson.MarshalOptions{UseProtoNames: true}
+	jsonUnmarshal = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+func (jsonlCodec) WriteMessage(w io.Writer, msg proto.Message) error {
+	payload, err := jsonMarshal.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	// protojson output is randomly spaced but never spans lines unless Multiline
+	// is set; compact it so the framing does not depend on that.
+	var line bytes.Buffer
+	if err := json.Compact(&line, payload); err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	line.WriteByte('\n')
+
+	if _, err := w.Write(line.Bytes()); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+	return nil
+}
+
+// ReadMessage reads one line and decodes it into msg. Like ReadDelimited it
+// never consumes bytes past the end of the line. Blank lines are skipped.
+func (jsonlCodec) ReadMessage(r io.Reader, msg proto.Message) error {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = byteReader{r}
+	}
+
+	for {
+		line, err := readLine(br)
+		if errors.Is(err, io.EOF) && len(bytes.TrimSpace(line)) > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return fmt.Errorf("read message: %w", err)
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if err := jsonUnmarshal.Unmarshal(line, msg); err != nil {
+			return fmt.Errorf("unmarshal message: %w", err)
+		}
+		return nil
+	}
+}
+
+func readLine(br io.ByteReader) ([]byte, error) {
+	var line []byte
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return line, err
+		}
+		if b == '\n' {
+			return line, nil
+		}
+		line = append(line, b)
+	}
+}
@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestJSONLCodecRoundTrip(t *testing.T) {
+	c, err := CodecFor(ProtocolJSONL)
+	if err != nil {
+		t.Fatalf("CodecFor returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	first := &SecretRequest{Ref: "secret/app#password", Options: []byte("field: password\n"), Check: true}
+	second := &SecretRequest{Ref: "secret/app#user"}
+	for _, msg := range []proto.Message{first, second} {
+		if err := c.WriteMessage(&buf, msg); err != nil {
+			t.Fatalf("WriteMessage returned error: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per message, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], `"ref":"secret/app#password"`) {
+		t.Fatalf("expected snake_case JSON fields, got %s", lines[0])
+	}
+
+	for _, want := range []*SecretRequest{first, second} {
+		got := &SecretRequest{}
+		if err := c.ReadMessage(&buf, got); err != nil {
+			t.Fatalf("ReadMessage returned error: %v", err)
+		}
+		if !proto.Equal(got, want) {
+			t.Fatalf("ReadMessage = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestJSONLCodecAcceptsHandWrittenLines(t *testing.T) {
+	c, _ := CodecFor(ProtocolJSONL)
+	in := strings.NewReader("\n{\"value\": \"aHVudGVyMg==\", \"extra\": 1}\n")
+
+	resp := &SecretResponse{}
+	if err := c.ReadMessage(in, resp); err != nil {
+		t.Fatalf("ReadMessage returned error: %v", err)
+	}
+	if string(resp.GetValue()) != "hunter2" {
+		t.Fatalf("value = %q, want %q", resp.GetValue(), "hunter2")
+	}
+}
+
+func TestCodecForRejectsUnknownProtocol(t *testing.T) {
+	if _, err := CodecFor("xml"); err == nil {
+		t.Fatal("expected error for unknown protocol")
+	}
+}
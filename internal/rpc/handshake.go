@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// ProtocolVersion is the plugin protocol revision spoken by this package.
+	ProtocolVersion = 1
+
+	// HandshakeCookieKey is the environment variable the host sets when launching a plugin.
+	HandshakeCookieKey = "SFX_PLUGIN_MAGIC_COOKIE"
+	// HandshakeCookieValue marks a process as being driven by the sfx host.
+	HandshakeCookieValue = "d3b2c1a0-sfx-plugin"
+
+	// ModePersistent keeps a plugin process alive across requests.
+	ModePersistent = "persistent"
+	// ModeOneShot spawns a fresh plugin process for every request.
+	ModeOneShot = "oneshot"
+)
+
+// HostManaged reports whether the current process was launched by the sfx host.
+func HostManaged() bool {
+	return os.Getenv(HandshakeCookieKey) == HandshakeCookieValue
+}
+
+// WriteHandshake announces the plugin to the host when running under sfx.
+// Outside of the host (e.g. when piping requests by hand) it is a no-op.
+func WriteHandshake(c Codec, w io.Writer, hs *Handshake) error {
+	if !HostManaged() {
+		return nil
+	}
+	if hs.GetProtocolVersion() == 0 {
+		hs.ProtocolVersion = ProtocolVersion
+	}
+	return c.WriteMessage(w, hs)
+}
+
+// ReadHandshake reads and checks the first message emitted by a plugin.
+func ReadHandshake(c Codec, r io.Reader) (*Handshake, error) {
+	hs := &Handshake{}
+	if err := c.ReadMessage(r, hs); err != nil {
+		return nil, fmt.Errorf("handshake: %w", err)
+	}
+	if hs.GetProtocolVersion() != ProtocolVersion {
+		return nil, fmt.Errorf("handshake: unsupported protocol version %d (want %d)", hs.GetProtocolVersion(), ProtocolVersion)
+	}
+	switch hs.GetMode() {
+	case "", ModePersistent, ModeOneShot:
+	default:
+		return nil, fmt.Errorf("handshake: unknown mode %q", hs.GetMode())
+	}
+	return hs, nil
+}
@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHandshakeRoundTrip(t *testing.T) {
+	t.Setenv(HandshakeCookieKey, HandshakeCookieValue)
+
+	for _, protocol := range []string{ProtocolProtobuf, ProtocolJSONL} {
+		t.Run(protocol, func(t *testing.T) {
+			c, err := CodecFor(protocol)
+			if err != nil {
+				t.Fatalf("CodecFor returned error: %v", err)
+			}
+
+			var buf bytes.Buffer
+			sent := &Handshake{Kind: "provider", Mode: ModeOneShot}
+			if err := WriteHandshake(c, &buf, sent); err != nil {
+				t.Fatalf("WriteHandshake returned error: %v", err)
+			}
+			got, err := ReadHandshake(c, &buf)
+			if err != nil {
+				t.Fatalf("ReadHandshake returned error: %v", err)
+			}
+			want := &Handshake{ProtocolVersion: ProtocolVersion, Kind: "provider", Mode: ModeOneShot}
+			if !proto.Equal(got, want) {
+				t.Fatalf("ReadHandshake = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestWriteHandshakeNeedsMagicCookie(t *testing.T) {
+	for _, cookie := range []string{"", "not-the-cookie"} {
+		t.Setenv(HandshakeCookieKey, cookie)
+
+		var buf bytes.Buffer
+		if err := WriteHandshake(delimitedCodec{}, &buf, &Handshake{Kind: "provider"}); err != nil {
+			t.Fatalf("WriteHandshake returned error: %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Fatalf("cookie %q: WriteHandshake wrote %d bytes, want none", cookie, buf.Len())
+		}
+	}
+}
+
+func TestReadHandshakeRejectsMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		hs   *Handshake
+		want string
+	}{
+		{name: "missing version", hs: &Handshake{Kind: "provider"}, want: "unsupported protocol version 0"},
+		{name: "newer version", hs: &Handshake{ProtocolVersion: ProtocolVersion + 1}, want: "unsupported protocol version 2"},
+		{name: "unknown mode", hs: &Handshake{ProtocolVersion: ProtocolVersion, Mode: "forever"}, want: `unknown mode "forever"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (delimitedCodec{}).WriteMessage(&buf, tt.hs); err != nil {
+				t.Fatalf("WriteMessage returned error: %v", err)
+			}
+			_, err := ReadHandshake(delimitedCodec{}, &buf)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ReadHandshake error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// ListenKey makes a plugin serve requests on the given address instead of stdio,
+	// e.g. unix:///run/sfx/vault.sock or http://127.0.0.1:7070.
+	ListenKey = "SFX_PLUGIN_LISTEN"
+
+	// HandshakePath returns the plugin handshake.
+	HandshakePath = "/v1/handshake"
+	// CallPath accepts a single request message and returns the response.
+	CallPath = "/v1/call"
+
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSONL    = "application/json"
+)
+
+// ContentType returns the media type used on HTTP for the named protocol.
+func ContentType(protocol string) string {
+	if protocol == ProtocolJSONL {
+		return contentTypeJSONL
+	}
+	return contentTypeProtobuf
+}
+
+// protocolFor maps a Content-Type or Accept header back to a protocol name.
+func protocolFor(header string) string {
+	media, _, _ := mime.ParseMediaType(header)
+	if media == contentTypeJSONL {
+		return ProtocolJSONL
+	}
+	return ProtocolProtobuf
+}
+
+// IsRemote reports whether a plugin path is a unix:// or http(s):// endpoint.
+func IsRemote(path string) bool {
+	for _, scheme := range []string{"unix://", "http://", "https://"} {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// Listen opens a listener for a unix:// or http:// address.
+func Listen(addr string) (net.Listener, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("parse listen address: %w", err)
+	}
+	switch u.Scheme {
+	case "unix":
+		return net.Listen("unix", u.Path)
+	case "http":
+		return net.Listen("tcp", u.Host)
+	default:
+		return nil, fmt.Errorf("listen address %q must use unix:// or http://", addr)
+	}
+}
+
+// HostListenAddr returns the address requested through ListenKey, if any.
+func HostListenAddr() string {
+	return os.Getenv(ListenKey)
+}
+
+// HTTPHandler serves a plugin over HTTP. Bodies carry exactly one message,
+// encoded with the codec selected by the Content-Type (or Accept) header.
+type HTTPHandler struct {
+	// Handshake is returned from HandshakePath.
+	Handshake *Handshake
+	// NewRequest allocates the request message for CallPath.
+	NewRequest func() proto.Message
+	// Call handles a request. Handler failures must be reported inside the
+	// returned message, as they are on stdio.
+	Call func(r *http.Request, req proto.Message) proto.Message
+}
+
+// ServeHTTP implements http.Handler.
+func (h HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case HandshakePath:
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeHTTPMessage(w, protocolFor(r.Header.Get("Accept")), h.Handshake)
+
+	case CallPath:
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		protocol := protocolFor(r.Header.Get("Content-Type"))
+		codec, _ := CodecFor(protocol)
+
+		req := h.NewRequest()
+		if err := codec.ReadMessage(r.Body, req); err != nil {
+			http.Error(w, fmt.Sprintf("decode request: %v", err), http.StatusBadRequest)
+			return
+		}
+		writeHTTPMessage(w, protocol, h.Call(r, req))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeHTTPMessage(w http.ResponseWriter, protocol string, msg proto.Message) {
+	codec, _ := CodecFor(protocol)
+	w.Header().Set("Content-Type", ContentType(protocol))
+	_ = codec.WriteMessage(w, msg)
+}
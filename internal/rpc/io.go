@@ -2,7 +2,6 @@
 package rpc
 
 import (
-	"bufio"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -17,22 +16,25 @@ func WriteDelimited(w io.Writer, msg proto.Message) error {
 		return fmt.Errorf("marshal message: %w", err)
 	}
 
-	var lenBuf [binary.MaxVarintLen64]byte
-	n := binary.PutUvarint(lenBuf[:], uint64(len(payload)))
-
-	if _, err := w.Write(lenBuf[:n]); err != nil {
-		return fmt.Errorf("write length: %w", err)
-	}
-	if _, err := w.Write(payload); err != nil {
-		return fmt.Errorf("write payload: %w", err)
+	// A single write keeps the frame intact on pipes and never issues a
+	// zero-length write for empty messages, which synchronous pipes block on.
+	frame := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(payload)), uint64(len(payload)))
+	frame = append(frame, payload...)
+	if _, err := w.Write(frame); err != nil {
+		return fmt.Errorf("write message: %w", err)
 	}
 
 	return nil
 }
 
 // ReadDelimited reads a length-delimited protobuf message from r into msg.
+// It never consumes bytes past the end of the message, so consecutive calls on
+// the same stream are safe.
 func ReadDelimited(r io.Reader, msg proto.Message) error {
-	br := bufio.NewReader(r)
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = byteReader{r}
+	}
 
 	length, err := binary.ReadUvarint(br)
 	if err != nil {
@@ -40,7 +42,7 @@ func ReadDelimited(r io.Reader, msg proto.Message) error {
 	}
 
 	payload := make([]byte, int(length))
-	if _, err := io.ReadFull(br, payload); err != nil {
+	if _, err := io.ReadFull(r, payload); err != nil {
 		return fmt.Errorf("read payload: %w", err)
 	}
 
@@ -50,3 +52,16 @@ func ReadDelimited(r io.Reader, msg proto.Message) error {
 
 	return nil
 }
+
+// byteReader reads a single byte at a time from an unbuffered stream.
+type byteReader struct {
+	r io.Reader
+}
+
+func (b byteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	if _, err := io.ReadFull(b.r, buf[:]); err != nil {
+		return 0, err
+	}
+	return buf[0], nil
+}
@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+)
+
+const (
+	// LogLevelKey is the environment variable carrying the host log level to plugins.
+	LogLevelKey = "SFX_PLUGIN_LOG_LEVEL"
+
+	// StderrSyncMarker is written on its own stderr line once a request has been
+	// handled, letting the host attribute everything before it to that request.
+	StderrSyncMarker = "\x00sfx:sync"
+)
+
+// LogRecord is a structured log entry emitted by a plugin on stderr.
+// Records are single-line JSON objects with at least "level" and "msg" keys,
+// exactly as produced by slog's JSON handler.
+type LogRecord struct {
+	Level   slog.Level
+	Message string
+	Attrs   []any
+}
+
+// NewLogger returns a logger that writes log records for the host to w,
+// filtered by the level the host passed in the environment.
+func NewLogger(w io.Writer) *slog.Logger {
+	level := slog.LevelInfo
+	if raw := os.Getenv(LogLevelKey); raw != "" {
+		_ = level.UnmarshalText([]byte(raw))
+	}
+
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// WriteStderrSync emits StderrSyncMarker when running under the host.
+func WriteStderrSync(w io.Writer) {
+	if !HostManaged() {
+		return
+	}
+	_, _ = io.WriteString(w, StderrSyncMarker+"\n")
+}
+
+// ParseLogRecord decodes a stderr line into a LogRecord.
+// It reports false when the line is not a structured record.
+func ParseLogRecord(line []byte) (LogRecord, bool) {
+	if len(line) == 0 || line[0] != '{' {
+		return LogRecord{}, false
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return LogRecord{}, false
+	}
+
+	rawLevel, ok := fields[slog.LevelKey].(string)
+	if !ok {
+		return LogRecord{}, false
+	}
+	msg, ok := fields[slog.MessageKey].(string)
+	if !ok {
+		return LogRecord{}, false
+	}
+
+	var rec LogRecord
+	if err := rec.Level.UnmarshalText([]byte(rawLevel)); err != nil {
+		return LogRecord{}, false
+	}
+	rec.Message = msg
+
+	delete(fields, slog.LevelKey)
+	delete(fields, slog.MessageKey)
+	delete(fields, slog.TimeKey)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		rec.Attrs = append(rec.Attrs, k, fields[k])
+	}
+
+	return rec, true
+}
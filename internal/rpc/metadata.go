@@ -0,0 +1,54 @@
+package rpc
+
+import "time"
+
+// Metadata keys shared by providers and exporters. Providers set those their
+// backend reports; the host fills in MetaProvider and MetaRef.
+const (
+	// MetaVersion identifies the version of the secret that was read.
+	MetaVersion = "version"
+	// MetaCreated is when that version was created.
+	MetaCreated = "created"
+	// MetaUpdated is when the secret was last changed.
+	MetaUpdated = "updated"
+	// MetaExpires is when the secret stops being valid.
+	MetaExpires = "expires"
+	// MetaContentType is the media type recorded for the value.
+	MetaContentType = "content_type"
+	// MetaProvider names the configured provider that returned the secret.
+	MetaProvider = "provider"
+	// MetaRef is the ref the secret was read from.
+	MetaRef = "ref"
+)
+
+// WrapMetadata converts per-secret metadata to its wire form.
+func WrapMetadata(m map[string]map[string]string) map[string]*Metadata {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]*Metadata, len(m))
+	for name, entries := range m {
+		out[name] = &Metadata{Entries: entries}
+	}
+	return out
+}
+
+// UnwrapMetadata is the inverse of WrapMetadata.
+func UnwrapMetadata(m map[string]*Metadata) map[string]map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]map[string]string, len(m))
+	for name, md := range m {
+		out[name] = md.GetEntries()
+	}
+	return out
+}
+
+// FormatTime formats t as metadata: RFC 3339 in UTC, or "" for the zero time.
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,12 @@
+package rpc
+
+import "gopkg.in/yaml.v3"
+
+// MarshalOptions encodes plugin options the way the host sends them in requests:
+// as a YAML document, or nil when there are none.
+func MarshalOptions(options map[string]any) ([]byte, error) {
+	if len(options) == 0 {
+		return nil, nil
+	}
+	return yaml.Marshal(options)
+}
@@ -0,0 +1,285 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeError lists every problem found while decoding options.
+type DecodeError struct {
+	Violations []Violation
+}
+
+func (e *DecodeError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return "invalid options: " + strings.Join(msgs, "; ")
+}
+
+// Decode unmarshals the YAML options in raw into v, which must be a pointer to
+// a struct. Unlike yaml.Unmarshal it rejects keys that match no field, fills
+// fields from their `default` tags before decoding, reports fields tagged
+// `required:"true"` that are missing or empty, and parses time.Duration fields
+// as duration strings. Problems with the options are returned as a *DecodeError
+// naming each offending option.
+func Decode(raw []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("schema: decode target must be a non-nil pointer to a struct, got %T", v)
+	}
+	target := rv.Elem()
+
+	if err := applyDefaults(target); err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("parse options: %w", err)
+	}
+
+	d := decoder{seen: map[string]bool{}}
+	if len(doc.Content) > 0 {
+		d.object("", doc.Content[0], target)
+	}
+	d.required("", target)
+
+	if len(d.out) > 0 {
+		return &DecodeError{Violations: d.out}
+	}
+	return nil
+}
+
+type decoder struct {
+	seen map[string]bool
+	out  []Violation
+}
+
+func (d *decoder) report(path, format string, args ...any) {
+	d.out = append(d.out, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (d *decoder) object(path string, node *yaml.Node, v reflect.Value) {
+	node = resolve(node)
+	if isNull(node) {
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		d.report(path, "expected a mapping, got %s", nodeKind(node))
+		return
+	}
+
+	fields, names := structFields(v.Type())
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		child := joinPath(path, key)
+
+		index, ok := fields[key]
+		if !ok {
+			d.report(child, "%s", unknownOption(key, names))
+			continue
+		}
+		d.seen[child] = true
+		d.value(child, node.Content[i+1], v.FieldByIndex(index))
+	}
+}
+
+func (d *decoder) value(path string, node *yaml.Node, v reflect.Value) {
+	node = resolve(node)
+
+	switch {
+	case v.Type() == durationType:
+		if isNull(node) {
+			return
+		}
+		if node.Kind != yaml.ScalarNode {
+			d.report(path, "expected a duration, got %s", nodeKind(node))
+			return
+		}
+		dur, err := time.ParseDuration(node.Value)
+		if err != nil {
+			d.report(path, "invalid duration %q (use values like 30s or 5m)", node.Value)
+			return
+		}
+		if dur < 0 {
+			d.report(path, "duration %q must not be negative", node.Value)
+			return
+		}
+		v.SetInt(int64(dur))
+	case v.Kind() == reflect.Pointer:
+		if isNull(node) {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		d.value(path, node, v.Elem())
+	case v.Kind() == reflect.Struct:
+		d.object(path, node, v)
+	default:
+		if err := node.Decode(v.Addr().Interface()); err != nil {
+			d.report(path, "%s", typeErrorMessage(err))
+		}
+	}
+}
+
+// required reports fields tagged `required:"true"` that were not set to a
+// non-zero value, descending into nested structs that were given.
+func (d *decoder) required(path string, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, inline := yamlName(field)
+		if name == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if inline {
+			if fv.Kind() == reflect.Struct {
+				d.required(path, fv)
+			}
+			continue
+		}
+
+		child := joinPath(path, name)
+		if field.Tag.Get("required") == "true" && (!d.seen[child] || fv.IsZero()) {
+			d.report(child, "required option is not set")
+			continue
+		}
+		if fv.Kind() == reflect.Struct && fv.Type() != durationType && d.seen[child] {
+			d.required(child, fv)
+		}
+	}
+}
+
+// applyDefaults sets zero fields of v from their `default` tags. A malformed
+// tag is a programming error in the plugin and is reported as such.
+func applyDefaults(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+		def, ok := field.Tag.Lookup("default")
+		if !ok {
+			if fv.Kind() == reflect.Struct && fv.Type() != durationType {
+				if err := applyDefaults(fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !fv.IsZero() {
+			continue
+		}
+
+		switch {
+		case fv.Type() == durationType:
+			dur, err := time.ParseDuration(def)
+			if err != nil {
+				return fmt.Errorf("schema: default for field %s: %w", field.Name, err)
+			}
+			fv.SetInt(int64(dur))
+			continue
+		case fv.Kind() == reflect.String:
+			// Taken verbatim: templates and shebangs are not valid YAML scalars.
+			fv.SetString(def)
+			continue
+		}
+		if err := yaml.Unmarshal([]byte(def), fv.Addr().Interface()); err != nil {
+			return fmt.Errorf("schema: default for field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// structFields maps the yaml names of t's fields, including those of inlined
+// structs, to their field index, and returns the sorted list of names.
+func structFields(t reflect.Type) (map[string][]int, []string) {
+	fields := map[string][]int{}
+	var walk func(t reflect.Type, prefix []int)
+	walk = func(t reflect.Type, prefix []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, inline := yamlName(field)
+			if name == "-" {
+				continue
+			}
+			index := append(append([]int(nil), prefix...), i)
+			if inline && field.Type.Kind() == reflect.Struct {
+				walk(field.Type, index)
+				continue
+			}
+			fields[name] = index
+		}
+	}
+	walk(t, nil)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fields, names
+}
+
+func resolve(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return resolve(node.Content[0])
+	}
+	return node
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == 0 || (node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null")
+}
+
+func nodeKind(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return fmt.Sprintf("%s %q", strings.TrimPrefix(node.ShortTag(), "!!"), node.Value)
+	}
+}
+
+var yamlLinePrefix = regexp.MustCompile(`^line \d+: `)
+
+// typeErrorMessage strips the "yaml: unmarshal errors" preamble and line
+// numbers, which refer to the re-encoded options rather than the user's file.
+func typeErrorMessage(err error) string {
+	var te *yaml.TypeError
+	if !errors.As(err, &te) {
+		return err.Error()
+	}
+	msgs := make([]string, len(te.Errors))
+	for i, msg := range te.Errors {
+		msgs[i] = yamlLinePrefix.ReplaceAllString(msg, "")
+	}
+	return strings.Join(msgs, "; ")
+}
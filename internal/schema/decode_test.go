@@ -0,0 +1,133 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Connection struct {
+	Host string `yaml:"host" required:"true"`
+	Port int    `yaml:"port" default:"8200"`
+}
+
+type decodeOptions struct {
+	Connection `yaml:",inline"`
+	Timeout    time.Duration `yaml:"timeout" default:"30s"`
+	Verify     *bool         `yaml:"verify" default:"true"`
+	Order      []string      `yaml:"order"`
+	TLS        struct {
+		CA string `yaml:"ca" required:"true"`
+	} `yaml:"tls"`
+}
+
+func TestDecodeAppliesDefaultsAndValues(t *testing.T) {
+	var opts decodeOptions
+	err := Decode([]byte("host: vault\ntimeout: 5m\norder: [b, a]\n"), &opts)
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if opts.Host != "vault" || opts.Port != 8200 {
+		t.Fatalf("connection = %+v, want host vault and default port", opts.Connection)
+	}
+	if opts.Timeout != 5*time.Minute {
+		t.Fatalf("Timeout = %v, want 5m", opts.Timeout)
+	}
+	if opts.Verify == nil || !*opts.Verify {
+		t.Fatalf("Verify = %v, want default true", opts.Verify)
+	}
+	if strings.Join(opts.Order, ",") != "b,a" {
+		t.Fatalf("Order = %v", opts.Order)
+	}
+}
+
+func TestDecodeKeepsExplicitZeroValues(t *testing.T) {
+	var opts decodeOptions
+	if err := Decode([]byte("host: vault\nverify: false\n"), &opts); err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	if opts.Verify == nil || *opts.Verify {
+		t.Fatalf("Verify = %v, want explicit false", opts.Verify)
+	}
+}
+
+func TestDecodeReportsEveryViolation(t *testing.T) {
+	var opts decodeOptions
+	err := Decode([]byte("timeout: soon\nport: [1]\nhots: vault\ntls: {}\n"), &opts)
+
+	var derr *DecodeError
+	if !errors.As(err, &derr) {
+		t.Fatalf("Decode error = %v, want *DecodeError", err)
+	}
+
+	got := map[string]string{}
+	for _, v := range derr.Violations {
+		got[v.Path] = v.Message
+	}
+	want := map[string]string{
+		"timeout": "invalid duration",
+		"port":    "cannot unmarshal",
+		"hots":    `unknown option "hots" (accepted: host, order, port, timeout, tls, verify)`,
+		"host":    "required option is not set",
+		"tls.ca":  "required option is not set",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("violations = %v, want paths %v", derr.Violations, want)
+	}
+	for path, msg := range want {
+		if !strings.Contains(got[path], msg) {
+			t.Errorf("violation at %q = %q, want it to contain %q", path, got[path], msg)
+		}
+	}
+}
+
+func TestDecodeRejectsNegativeDurations(t *testing.T) {
+	var opts decodeOptions
+	err := Decode([]byte("host: vault\ntimeout: -1s\n"), &opts)
+	if err == nil || !strings.Contains(err.Error(), "timeout: duration \"-1s\" must not be negative") {
+		t.Fatalf("Decode error = %v, want negative duration violation", err)
+	}
+}
+
+func TestDecodeAcceptsEmptyOptions(t *testing.T) {
+	var opts struct {
+		Prefix string `yaml:"prefix" default:"{{ .Key }}"`
+	}
+	for _, raw := range []string{"", "# nothing\n", "null\n"} {
+		opts.Prefix = ""
+		if err := Decode([]byte(raw), &opts); err != nil {
+			t.Fatalf("Decode(%q) returned error: %v", raw, err)
+		}
+		if opts.Prefix != "{{ .Key }}" {
+			t.Fatalf("Decode(%q) Prefix = %q, want default", raw, opts.Prefix)
+		}
+	}
+}
+
+func TestDecodeRejectsNonMappingOptions(t *testing.T) {
+	var opts decodeOptions
+	err := Decode([]byte("- a\n"), &opts)
+	if err == nil || !strings.Contains(err.Error(), "expected a mapping, got a list") {
+		t.Fatalf("Decode error = %v, want mapping violation", err)
+	}
+}
+
+func TestGeneratePublishesDefaultsAndRequired(t *testing.T) {
+	s, err := Generate(decodeOptions{})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if strings.Join(s.Required, ",") != "host" {
+		t.Fatalf("Required = %v, want [host]", s.Required)
+	}
+	if s.Properties["timeout"].Default != "30s" || s.Properties["verify"].Default != true || s.Properties["port"].Default != 8200 {
+		t.Fatalf("defaults = %v, %v, %v", s.Properties["timeout"].Default, s.Properties["verify"].Default, s.Properties["port"].Default)
+	}
+
+	violations := s.Validate(map[string]any{"port": 1})
+	if len(violations) != 1 || violations[0].String() != "host: required option is not set" {
+		t.Fatalf("Validate = %v, want missing host", violations)
+	}
+}
@@ -0,0 +1,167 @@
+// Package schema derives JSON Schemas for plugin options from Go types.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Draft is the JSON Schema dialect emitted by this package.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used to describe plugin options.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Generate builds the schema for v, which is typically a zero value of a plugin's
+// options struct. Property names follow the yaml struct tags, descriptions are
+// taken from `desc` tags and defaults and required properties from the `default`
+// and `required` tags understood by Decode.
+func Generate(v any) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("schema: nil value")
+	}
+
+	s, err := fromType(t)
+	if err != nil {
+		return nil, err
+	}
+	s.Schema = Draft
+	return s, nil
+}
+
+// Marshal returns the JSON encoding of the schema generated for v.
+func Marshal(v any) ([]byte, error) {
+	s, err := Generate(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+func fromType(t reflect.Type) (*Schema, error) {
+	if t == durationType {
+		return &Schema{Type: "string", Format: "duration"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return fromType(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := fromType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("schema: unsupported map key type %s", t.Key())
+		}
+		values, err := fromType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return fromStruct(t)
+	case reflect.Interface:
+		return &Schema{}, nil
+	default:
+		return nil, fmt.Errorf("schema: unsupported type %s", t)
+	}
+}
+
+func fromStruct(t reflect.Type) (*Schema, error) {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline := yamlName(field)
+		if name == "-" {
+			continue
+		}
+
+		prop, err := fromType(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		if inline {
+			for k, v := range prop.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, prop.Required...)
+			continue
+		}
+
+		prop.Description = field.Tag.Get("desc")
+		if def, ok := field.Tag.Lookup("default"); ok {
+			prop.Default = defaultValue(def, field.Type)
+		}
+		if field.Tag.Get("required") == "true" {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+
+	return s, nil
+}
+
+// defaultValue converts a `default` tag into the value published in the schema.
+// Durations stay strings; everything else is decoded as YAML.
+func defaultValue(tag string, t reflect.Type) any {
+	if t == durationType || t.Kind() == reflect.String {
+		return tag
+	}
+	var v any
+	if err := yaml.Unmarshal([]byte(tag), &v); err != nil {
+		return tag
+	}
+	return v
+}
+
+// yamlName mirrors gopkg.in/yaml.v3 field naming: the tag name when present,
+// otherwise the lower-cased field name.
+func yamlName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("yaml")
+	name, flags, _ := strings.Cut(tag, ",")
+	inline := strings.Contains(","+flags+",", ",inline,")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, inline
+}
@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type sampleOptions struct {
+	Address string            `yaml:"address" desc:"Server address"`
+	Timeout time.Duration     `yaml:"timeout"`
+	Verify  *bool             `yaml:"verify"`
+	Order   []string          `yaml:"order"`
+	Labels  map[string]string `yaml:"labels"`
+	Nested  struct {
+		Left string `yaml:"left"`
+	} `yaml:"nested"`
+	Ignored string `yaml:"-"`
+	hidden  string
+}
+
+func TestGenerateFollowsYAMLTags(t *testing.T) {
+	s, err := Generate(sampleOptions{})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	if s.Type != "object" || s.AdditionalProperties != false {
+		t.Fatalf("expected closed object schema, got %+v", s)
+	}
+	if len(s.Properties) != 6 {
+		t.Fatalf("expected 6 properties, got %d: %v", len(s.Properties), s.Properties)
+	}
+
+	checks := map[string]string{
+		"address": "string",
+		"timeout": "string",
+		"verify":  "boolean",
+		"order":   "array",
+		"labels":  "object",
+		"nested":  "object",
+	}
+	for name, typ := range checks {
+		if got := s.Properties[name].Type; got != typ {
+			t.Fatalf("property %q type = %q, want %q", name, got, typ)
+		}
+	}
+	if s.Properties["timeout"].Format != "duration" {
+		t.Fatalf("timeout should use the duration format")
+	}
+	if s.Properties["address"].Description != "Server address" {
+		t.Fatalf("description not taken from desc tag")
+	}
+	if s.Properties["nested"].Properties["left"] == nil {
+		t.Fatalf("nested struct properties missing")
+	}
+
+	raw, err := Marshal(sampleOptions{})
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	if !json.Valid(raw) {
+		t.Fatalf("Marshal produced invalid JSON: %s", raw)
+	}
+}
@@ -0,0 +1,249 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Violation is a single mismatch between a value and its schema.
+type Violation struct {
+	// Path locates the offending value, e.g. "labels.team" or "order[2]".
+	Path    string
+	Message string
+}
+
+// String formats the violation as "path: message".
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// Parse decodes a JSON Schema produced by Marshal.
+func Parse(raw []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	return &s, nil
+}
+
+// UnmarshalJSON decodes additionalProperties into either a bool or a *Schema.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type plain Schema
+	var aux struct {
+		plain
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*s = Schema(aux.plain)
+
+	switch raw := strings.TrimSpace(string(aux.AdditionalProperties)); raw {
+	case "":
+		s.AdditionalProperties = nil
+	case "true", "false":
+		s.AdditionalProperties = raw == "true"
+	default:
+		var sub Schema
+		if err := json.Unmarshal(aux.AdditionalProperties, &sub); err != nil {
+			return err
+		}
+		s.AdditionalProperties = &sub
+	}
+	return nil
+}
+
+// Validate checks value, as decoded from YAML, against s and returns every violation found.
+func (s *Schema) Validate(value any) []Violation {
+	var out []Violation
+	s.validate("", value, &out)
+	return out
+}
+
+func (s *Schema) validate(path string, value any, out *[]Violation) {
+	if s == nil || value == nil {
+		return
+	}
+
+	report := func(format string, args ...any) {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "string":
+		if !isScalar(value) {
+			report("expected a string, got %s", describe(value))
+			return
+		}
+		if s.Format == "duration" {
+			if str, ok := value.(string); ok {
+				if _, err := time.ParseDuration(str); err != nil {
+					report("invalid duration %q (use values like 30s or 5m)", str)
+				}
+			} else if _, ok := asNumber(value); !ok {
+				report("expected a duration, got %s", describe(value))
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			report("expected a boolean, got %s", describe(value))
+		}
+	case "integer":
+		n, ok := asNumber(value)
+		if !ok || n != math.Trunc(n) {
+			report("expected an integer, got %s", describe(value))
+		}
+	case "number":
+		if _, ok := asNumber(value); !ok {
+			report("expected a number, got %s", describe(value))
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			report("expected a list, got %s", describe(value))
+			return
+		}
+		for i, item := range items {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, out)
+		}
+	case "object":
+		obj, ok := asObject(value)
+		if !ok {
+			report("expected a mapping, got %s", describe(value))
+			return
+		}
+		s.validateObject(path, obj, out)
+	}
+}
+
+func (s *Schema) validateObject(path string, obj map[string]any, out *[]Violation) {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := joinPath(path, key)
+		if prop, ok := s.Properties[key]; ok {
+			prop.validate(child, obj[key], out)
+			continue
+		}
+
+		switch extra := s.AdditionalProperties.(type) {
+		case bool:
+			if !extra {
+				*out = append(*out, Violation{Path: child, Message: unknownOption(key, propertyNames(s.Properties))})
+			}
+		case *Schema:
+			extra.validate(child, obj[key], out)
+		}
+	}
+
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			*out = append(*out, Violation{Path: joinPath(path, name), Message: "required option is not set"})
+		}
+	}
+}
+
+func unknownOption(key string, known []string) string {
+	if len(known) == 0 {
+		return fmt.Sprintf("unknown option %q", key)
+	}
+	return fmt.Sprintf("unknown option %q (accepted: %s)", key, strings.Join(known, ", "))
+}
+
+func propertyNames(props map[string]*Schema) []string {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, bool:
+		return true
+	}
+	_, ok := asNumber(v)
+	return ok
+}
+
+func asNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func asObject(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		out := make(map[string]any, len(m))
+		for k, val := range m {
+			out[fmt.Sprint(k)] = val
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func describe(v any) string {
+	switch t := v.(type) {
+	case string:
+		return "string " + strconv.Quote(t)
+	case bool:
+		return fmt.Sprintf("boolean %t", t)
+	case []any:
+		return "a list"
+	case map[string]any, map[any]any:
+		return "a mapping"
+	}
+	if n, ok := asNumber(v); ok {
+		return "number " + strconv.FormatFloat(n, 'g', -1, 64)
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,47 @@
+// Package ansible renders secrets as an Ansible variables file.
+package ansible
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+type options struct {
+	keys.Options `yaml:",inline"`
+}
+
+// Options returns the handshake options describing the exporter.
+func Options() []exporter.Option {
+	return []exporter.Option{
+		exporter.WithOptions(options{}),
+	}
+}
+
+// Handle serves a single exporter request.
+func Handle(req exporter.Request) (exporter.Response, error) {
+	opts, err := exporter.DecodeOptions[options](req.Options)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	ks, err := opts.Apply(req, keys.AnsibleVar)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	mapping, err := keys.TypedMapping(ks)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	payload, err := yaml.Marshal(mapping)
+	if err != nil {
+		return exporter.Response{}, fmt.Errorf("marshal yaml: %w", err)
+	}
+
+	return exporter.Response{Payload: payload}, nil
+}
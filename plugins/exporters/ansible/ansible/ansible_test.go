@@ -0,0 +1,63 @@
+package ansible
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+func TestHandleAnsible(t *testing.T) {
+	opts := options{Options: keys.Options{Prefix: "secret_", Order: []string{"B", "A"}}}
+	optsBytes, err := yaml.Marshal(opts)
+	if err != nil {
+		t.Fatalf("marshal options: %v", err)
+	}
+
+	req := exporter.Request{
+		Values: map[string][]byte{
+			"A": []byte("valueA"),
+			"B": []byte("valueB"),
+		},
+		Options: optsBytes,
+	}
+
+	resp, err := Handle(req)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	m := map[string]string{}
+	if err := yaml.Unmarshal(resp.Payload, &m); err != nil {
+		t.Fatalf("unmarshal yaml: %v", err)
+	}
+
+	if m["secret_A"] != "valueA" || m["secret_B"] != "valueB" {
+		t.Fatalf("unexpected map: %+v", m)
+	}
+	if want := "secret_B: valueB\nsecret_A: valueA\n"; string(resp.Payload) != want {
+		t.Fatalf("payload = %q, want %q", resp.Payload, want)
+	}
+}
+
+func TestHandleAnsibleHonoursDeclaredTypes(t *testing.T) {
+	req := exporter.Request{
+		Values: map[string][]byte{
+			"port":    []byte("8080"),
+			"zip":     []byte("01234"),
+			"enabled": []byte("yes"),
+		},
+		Types: map[string]string{"port": exporter.TypeInt},
+	}
+
+	resp, err := Handle(req)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	if want := "enabled: \"yes\"\nport: 8080\nzip: \"01234\"\n"; string(resp.Payload) != want {
+		t.Fatalf("payload = %q, want %q", resp.Payload, want)
+	}
+}
@@ -0,0 +1,203 @@
+// Package env renders secrets as a dotenv file.
+package env
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/sprig/v3"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+// Dialects accepted by the dialect option.
+const (
+	DialectPOSIX   = "posix"
+	DialectDocker  = "docker"
+	DialectCompose = "compose"
+	DialectNode    = "node"
+	DialectSystemd = "systemd"
+)
+
+type options struct {
+	keys.Options `yaml:",inline"`
+	Dialect      string   `yaml:"dialect" default:"posix" desc:"Quoting rules of the reader: posix, docker (--env-file), compose, node (dotenv) or systemd (EnvironmentFile)"`
+	Export       bool     `yaml:"export" desc:"Prefix each line with export (posix, compose and node only)"`
+	Metadata     []string `yaml:"metadata" desc:"Metadata fields written as a comment above each key, e.g. ref or version"`
+	Provenance   bool     `yaml:"provenance" desc:"Write the provider and ref of each secret as a comment above it"`
+}
+
+// dialect is how one kind of reader parses an env file.
+type dialect struct {
+	// format is what the reader accepts as a variable name.
+	format keys.Format
+	// export reports whether the reader accepts an "export " prefix.
+	export bool
+	// quote formats a value so the reader reads it back unchanged.
+	quote func(string) (string, error)
+}
+
+var dialects = map[string]dialect{
+	DialectPOSIX:   {format: keys.EnvVar, export: true, quote: quotePOSIX},
+	DialectDocker:  {format: keys.DotenvKey, quote: quoteDocker},
+	DialectCompose: {format: keys.DotenvKey, export: true, quote: quoteCompose},
+	DialectNode:    {format: keys.DotenvKey, export: true, quote: quoteNode},
+	DialectSystemd: {format: keys.EnvVar, quote: quoteSystemd},
+}
+
+// Options returns the handshake options describing the exporter.
+func Options() []exporter.Option {
+	return []exporter.Option{
+		exporter.WithOptions(options{}),
+	}
+}
+
+// Handle serves a single exporter request.
+func Handle(req exporter.Request) (exporter.Response, error) {
+	opts, err := exporter.DecodeOptions[options](req.Options)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	if opts.Dialect == "" {
+		opts.Dialect = DialectPOSIX
+	}
+	d, ok := dialects[opts.Dialect]
+	if !ok {
+		return exporter.Response{}, fmt.Errorf("unknown dialect %q (use %s, %s, %s, %s or %s)", opts.Dialect, DialectPOSIX, DialectDocker, DialectCompose, DialectNode, DialectSystemd)
+	}
+	if opts.Export && !d.export {
+		return exporter.Response{}, fmt.Errorf("export is not supported by the %s dialect", opts.Dialect)
+	}
+
+	// Without key options, keys are upper-cased as dotenv files expect.
+	if opts.KeyTemplate == "" && opts.KeyCase == "" {
+		opts.KeyCase = "upper"
+	}
+	opts.Funcs = sprig.TxtFuncMap()
+
+	ks, err := opts.Apply(req, d.format)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	fields := opts.Metadata
+	if opts.Provenance {
+		fields = append([]string{exporter.MetaProvider, exporter.MetaRef}, fields...)
+	}
+
+	var b strings.Builder
+	for _, k := range ks {
+		value := string(k.Value)
+		if strings.ContainsRune(value, 0) {
+			return exporter.Response{}, fmt.Errorf("secret %q: environment variables cannot hold NUL bytes", k.Secret)
+		}
+		quoted, err := d.quote(value)
+		if err != nil {
+			return exporter.Response{}, fmt.Errorf("secret %q: %w", k.Secret, err)
+		}
+
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		if c := comment(k.Metadata, fields); c != "" {
+			b.WriteString(c)
+			b.WriteByte('\n')
+		}
+		if opts.Export {
+			b.WriteString("export ")
+		}
+		b.WriteString(k.Name)
+		b.WriteByte('=')
+		b.WriteString(quoted)
+	}
+	b.WriteByte('\n')
+
+	return exporter.Response{Payload: []byte(b.String())}, nil
+}
+
+// comment renders the selected metadata fields as "# field=value ...", or ""
+// when the secret has none of them.
+func comment(md map[string]string, fields []string) string {
+	var parts []string
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		if v := md[f]; v != "" {
+			parts = append(parts, f+"="+strings.ReplaceAll(v, "\n", " "))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "# " + strings.Join(parts, " ")
+}
+
+// plainValue matches values every dialect reads unquoted.
+var plainValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]+$`)
+
+// quotePOSIX double-quotes values for sh, which keeps newlines inside the
+// quotes and needs \, ", $ and ` escaped.
+func quotePOSIX(s string) (string, error) {
+	if plainValue.MatchString(s) {
+		return s, nil
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`").Replace(s) + `"`, nil
+}
+
+// quoteDocker returns s as is: docker run --env-file takes everything after
+// the first = literally, quotes included, and has no way to continue a line.
+func quoteDocker(s string) (string, error) {
+	if strings.ContainsAny(s, "\r\n") {
+		return "", errors.New("the docker dialect cannot hold multi-line values")
+	}
+	return s, nil
+}
+
+// quoteCompose single-quotes values for docker compose, which interpolates
+// ${VAR} everywhere else. Values with a single quote or a line break are
+// double-quoted instead, with escapes and $$ for a literal $.
+func quoteCompose(s string) (string, error) {
+	if plainValue.MatchString(s) {
+		return s, nil
+	}
+	if !strings.ContainsAny(s, "'\r\n") {
+		return "'" + s + "'", nil
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", "$$").Replace(s) + `"`, nil
+}
+
+// quoteNode quotes values for the dotenv package, which strips a pair of ',
+// " or ` quotes without unescaping anything inside them, but expands \n and
+// \r within double quotes. The first quote character absent from the value is
+// used.
+func quoteNode(s string) (string, error) {
+	if plainValue.MatchString(s) {
+		return s, nil
+	}
+	for _, q := range []string{"'", "`", `"`} {
+		if strings.Contains(s, q) {
+			continue
+		}
+		if q == `"` && (strings.Contains(s, `\n`) || strings.Contains(s, `\r`)) {
+			continue
+		}
+		return q + s + q, nil
+	}
+	return "", errors.New("the node dialect cannot quote a value that contains ', ` and \" together")
+}
+
+// quoteSystemd double-quotes values for a systemd EnvironmentFile, where
+// quotes may span lines and \, ", ` and $ are escaped with a backslash.
+func quoteSystemd(s string) (string, error) {
+	if plainValue.MatchString(s) {
+		return s, nil
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`).Replace(s) + `"`, nil
+}
@@ -0,0 +1,147 @@
+package env
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/exportertest"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+func TestHandleEnvDefault(t *testing.T) {
+	req := exporter.Request{
+		Values: map[string][]byte{
+			"db_password": []byte("hunter2"),
+			"API_TOKEN":   []byte("value with spaces"),
+			"EMPTY":       []byte(""),
+		},
+	}
+
+	resp, err := Handle(req)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	got := string(resp.Payload)
+	want := "API_TOKEN=\"value with spaces\"\nEMPTY=\"\"\nDB_PASSWORD=hunter2\n"
+
+	if got != want {
+		t.Fatalf("unexpected payload:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestHandleEnvCustomTemplate(t *testing.T) {
+	optsBytes, err := yaml.Marshal(options{Options: keys.Options{KeyTemplate: "{{ .Value | lower }}"}})
+	if err != nil {
+		t.Fatalf("marshal options: %v", err)
+	}
+
+	req := exporter.Request{
+		Values: map[string][]byte{
+			"API_TOKEN": []byte("secret"),
+		},
+		Options: optsBytes,
+	}
+
+	resp, err := Handle(req)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	got := string(resp.Payload)
+	want := "api_token=secret\n"
+
+	if got != want {
+		t.Fatalf("unexpected payload:\nwant %q\ngot  %q", want, got)
+	}
+}
+
+func TestServesOverPluginProtocol(t *testing.T) {
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+
+	p.ExpectPayload(map[string]string{"db": "s3cret"}, nil, "DB=s3cret\n")
+	p.ExpectPayload(map[string]string{"db": "s3cret"}, map[string]any{"key_template": "APP_{{ .Value | upper }}"}, "APP_DB=s3cret\n")
+	p.ExpectError(map[string]string{"db": "s3cret"}, map[string]any{"key_template": "{{"}, "parse key template")
+	p.ExpectError(map[string]string{"db": "s3cret"}, map[string]any{"key_tempalte": "x"}, `unknown option "key_tempalte"`)
+}
+
+func TestHandleEnvMetadataComments(t *testing.T) {
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+
+	got, err := p.ExportMetadata(
+		map[string]string{"db": "s3cret", "api": "k"},
+		map[string]map[string]string{"db": {exporter.MetaRef: "secret/app#db", exporter.MetaVersion: "3"}},
+		map[string]any{"metadata": []string{"version", "ref"}},
+	)
+	if err != nil {
+		t.Fatalf("Export returned error: %v", err)
+	}
+
+	want := "API=k\n# version=3 ref=secret/app#db\nDB=s3cret\n"
+	if string(got) != want {
+		t.Fatalf("unexpected payload:\nwant %q\ngot  %q", want, got)
+	}
+}
+
+func TestHandleEnvDialects(t *testing.T) {
+	values := map[string]string{
+		"plain":  "abc-1.2:3/4",
+		"spaces": `it's "q" $HOME \ x`,
+		"lines":  "a\nb",
+		"empty":  "",
+	}
+	tests := []struct {
+		dialect string
+		want    string
+	}{
+		{DialectPOSIX, "EMPTY=\"\"\nLINES=\"a\nb\"\nPLAIN=abc-1.2:3/4\nSPACES=\"it's \\\"q\\\" \\$HOME \\\\ x\"\n"},
+		{DialectCompose, "EMPTY=''\nLINES=\"a\\nb\"\nPLAIN=abc-1.2:3/4\nSPACES=\"it's \\\"q\\\" $$HOME \\\\ x\"\n"},
+		{DialectNode, "EMPTY=''\nLINES='a\nb'\nPLAIN=abc-1.2:3/4\nSPACES=`it's \"q\" $HOME \\ x`\n"},
+		{DialectSystemd, "EMPTY=\"\"\nLINES=\"a\nb\"\nPLAIN=abc-1.2:3/4\nSPACES=\"it's \\\"q\\\" \\$HOME \\\\ x\"\n"},
+	}
+
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			p.ExpectPayload(values, map[string]any{"dialect": tt.dialect}, tt.want)
+		})
+	}
+
+	delete(values, "lines")
+	p.ExpectPayload(values, map[string]any{"dialect": DialectDocker}, "EMPTY=\nPLAIN=abc-1.2:3/4\nSPACES=it's \"q\" $HOME \\ x\n")
+}
+
+func TestHandleEnvDialectRules(t *testing.T) {
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+
+	p.ExpectPayload(map[string]string{"db": "x"}, map[string]any{"export": true}, "export DB=x\n")
+	p.ExpectPayload(map[string]string{"db-password": "x"}, map[string]any{"dialect": DialectNode}, "DB-PASSWORD=x\n")
+	p.ExpectError(map[string]string{"db-password": "x"}, nil, "not a valid environment variable name")
+	p.ExpectError(map[string]string{"db": "x"}, map[string]any{"dialect": DialectDocker, "export": true}, "export is not supported by the docker dialect")
+	p.ExpectError(map[string]string{"db": "a\nb"}, map[string]any{"dialect": DialectDocker}, `secret "db": the docker dialect cannot hold multi-line values`)
+	p.ExpectError(map[string]string{"db": "'`\""}, map[string]any{"dialect": DialectNode}, "cannot quote")
+	p.ExpectError(map[string]string{"db": "x"}, map[string]any{"dialect": "ini"}, `unknown dialect "ini"`)
+	// Key templates are text templates, so the key is rejected as written
+	// rather than HTML-escaped.
+	p.ExpectError(map[string]string{"db": "x"}, map[string]any{"key_template": "{{ .Key }}&<"}, `"db&<" is not a valid environment variable name`)
+}
+
+func TestHandleEnvProvenance(t *testing.T) {
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+
+	got, err := p.ExportMetadata(
+		map[string]string{"db": "s3cret"},
+		map[string]map[string]string{"db": {exporter.MetaProvider: "vault", exporter.MetaRef: "secret/app#db", exporter.MetaVersion: "3"}},
+		map[string]any{"provenance": true, "metadata": []string{"ref", "version"}},
+	)
+	if err != nil {
+		t.Fatalf("Export returned error: %v", err)
+	}
+
+	want := "# provider=vault ref=secret/app#db version=3\nDB=s3cret\n"
+	if string(got) != want {
+		t.Fatalf("unexpected payload:\nwant %q\ngot  %q", want, got)
+	}
+}
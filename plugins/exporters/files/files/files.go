@@ -0,0 +1,173 @@
+// Package files writes each secret to a file of its own, for services that
+// read their secrets from a directory such as /run/secrets.
+package files
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+// defaultMode is the mode the host gives files that do not ask for one; the
+// manifest reports it rather than zero.
+const defaultMode fs.FileMode = 0o600
+
+type options struct {
+	keys.Options `yaml:",inline"`
+
+	Mode        fs.FileMode            `yaml:"mode" desc:"Permissions of each file, e.g. 0400 (default 0600)"`
+	Modes       map[string]fs.FileMode `yaml:"modes" desc:"Permissions for individual secrets, by secret name"`
+	Directories map[string]string      `yaml:"directories" desc:"Subdirectory for files whose name starts with each prefix; the prefix is dropped from the file name"`
+	Manifest    string                 `yaml:"manifest" default:"manifest.json" desc:"Path of the JSON manifest listing the files, or empty to skip it"`
+	Prune       bool                   `yaml:"prune" default:"true" desc:"Remove files written by earlier runs for secrets that are no longer exported"`
+}
+
+type manifest struct {
+	Files []entry `json:"files"`
+}
+
+type entry struct {
+	Secret   string            `json:"secret"`
+	Path     string            `json:"path"`
+	Mode     string            `json:"mode"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Options returns the handshake options describing the exporter.
+func Options() []exporter.Option {
+	return []exporter.Option{
+		exporter.WithOptions(options{}),
+	}
+}
+
+// Handle serves a single exporter request.
+func Handle(req exporter.Request) (exporter.Response, error) {
+	opts, err := exporter.DecodeOptions[options](req.Options)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	modes, err := secretModes(opts)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+	place, err := placer(opts.Directories)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+	ks, err := opts.Apply(req, keys.FilePath)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	owner := make(map[string]string, len(ks)+1)
+	if opts.Manifest != "" {
+		if err := keys.FilePath.Check(opts.Manifest); err != nil {
+			return exporter.Response{}, fmt.Errorf("manifest: %w", err)
+		}
+		owner[opts.Manifest] = ""
+	}
+
+	resp := exporter.Response{Prune: opts.Prune}
+	m := manifest{Files: make([]entry, 0, len(ks))}
+	for _, k := range ks {
+		path, err := place(k.Name)
+		if err != nil {
+			return exporter.Response{}, fmt.Errorf("secret %q: %w", k.Secret, err)
+		}
+		if prev, ok := owner[path]; ok {
+			if prev == "" {
+				return exporter.Response{}, fmt.Errorf("secret %q is written to %q, the manifest path", k.Secret, path)
+			}
+			return exporter.Response{}, fmt.Errorf("secrets %q and %q are both written to %q", prev, k.Secret, path)
+		}
+		owner[path] = k.Secret
+
+		mode := opts.Mode
+		if override, ok := modes[strings.ToLower(k.Secret)]; ok {
+			mode = override
+		}
+		resp.Files = append(resp.Files, exporter.File{Path: path, Content: k.Value, Mode: mode})
+		m.Files = append(m.Files, entry{Secret: k.Secret, Path: path, Mode: formatMode(mode), Metadata: k.Metadata})
+	}
+
+	if opts.Manifest != "" {
+		content, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return exporter.Response{}, fmt.Errorf("marshal manifest: %w", err)
+		}
+		resp.Files = append(resp.Files, exporter.File{Path: opts.Manifest, Content: append(content, '\n'), Mode: opts.Mode})
+	}
+
+	return resp, nil
+}
+
+// secretModes checks the configured modes and returns the per-secret ones
+// keyed by lower-cased secret name, as the configuration loader lower-cases
+// secret names.
+func secretModes(opts options) (map[string]fs.FileMode, error) {
+	if err := checkMode(opts.Mode); err != nil {
+		return nil, fmt.Errorf("mode: %w", err)
+	}
+	out := make(map[string]fs.FileMode, len(opts.Modes))
+	for secret, mode := range opts.Modes {
+		if err := checkMode(mode); err != nil {
+			return nil, fmt.Errorf("modes[%q]: %w", secret, err)
+		}
+		out[strings.ToLower(secret)] = mode
+	}
+	return out, nil
+}
+
+func checkMode(mode fs.FileMode) error {
+	if mode&^fs.ModePerm != 0 {
+		return fmt.Errorf("%#o is not a permission mode (use values like 0400)", uint32(mode))
+	}
+	return nil
+}
+
+func formatMode(mode fs.FileMode) string {
+	if mode == 0 {
+		mode = defaultMode
+	}
+	return fmt.Sprintf("%04o", uint32(mode))
+}
+
+// placer returns a function from file name to path that moves names starting
+// with one of the prefixes in dirs into that prefix's directory, without the
+// prefix. The longest matching prefix wins.
+func placer(dirs map[string]string) (func(string) (string, error), error) {
+	prefixes := make([]string, 0, len(dirs))
+	for prefix, dir := range dirs {
+		if err := keys.FilePath.Check(dir); err != nil {
+			return nil, fmt.Errorf("directories[%q]: %w", prefix, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if len(prefixes[i]) != len(prefixes[j]) {
+			return len(prefixes[i]) > len(prefixes[j])
+		}
+		return prefixes[i] < prefixes[j]
+	})
+
+	return func(name string) (string, error) {
+		for _, prefix := range prefixes {
+			rest, ok := strings.CutPrefix(name, prefix)
+			if !ok {
+				continue
+			}
+			path := dirs[prefix] + "/" + rest
+			if err := keys.FilePath.Check(path); err != nil {
+				return "", fmt.Errorf("file %q under directory prefix %q: %w", name, prefix, err)
+			}
+			return path, nil
+		}
+		return name, nil
+	}, nil
+}
@@ -0,0 +1,106 @@
+package files
+
+import (
+	"encoding/json"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/exportertest"
+)
+
+func TestHandleWritesOneFilePerSecret(t *testing.T) {
+	resp, err := Handle(exporter.Request{
+		Values: map[string][]byte{
+			"db_password": []byte("p\n"),
+			"db_user":     []byte("app"),
+			"api_key":     []byte("k"),
+		},
+		Metadata: map[string]map[string]string{
+			"db_password": {exporter.MetaVersion: "3"},
+		},
+		Options: []byte("key_case: kebab\nmode: 0440\nmodes:\n  DB_PASSWORD: 0400\ndirectories:\n  db-: database\n"),
+	})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if !resp.Prune {
+		t.Fatal("Prune = false, want true by default")
+	}
+
+	got := map[string]exporter.File{}
+	for _, f := range resp.Files {
+		got[f.Path] = f
+	}
+	want := map[string]struct {
+		content string
+		mode    fs.FileMode
+	}{
+		"api-key":           {"k", 0o440},
+		"database/password": {"p\n", 0o400},
+		"database/user":     {"app", 0o440},
+	}
+	for path, w := range want {
+		f, ok := got[path]
+		if !ok {
+			t.Fatalf("no file %q in %v", path, resp.Files)
+		}
+		if string(f.Content) != w.content || f.Mode != w.mode {
+			t.Fatalf("%s = (%q, %#o), want (%q, %#o)", path, f.Content, f.Mode, w.content, w.mode)
+		}
+	}
+
+	var m manifest
+	if err := json.Unmarshal(got["manifest.json"].Content, &m); err != nil {
+		t.Fatalf("unmarshal manifest: %v", err)
+	}
+	wantManifest := []entry{
+		{Secret: "api_key", Path: "api-key", Mode: "0440"},
+		{Secret: "db_password", Path: "database/password", Mode: "0400", Metadata: map[string]string{exporter.MetaVersion: "3"}},
+		{Secret: "db_user", Path: "database/user", Mode: "0440"},
+	}
+	if !reflect.DeepEqual(m.Files, wantManifest) {
+		t.Fatalf("manifest = %+v, want %+v", m.Files, wantManifest)
+	}
+	if strings.Contains(string(got["manifest.json"].Content), "app") {
+		t.Fatal("manifest contains a secret value")
+	}
+}
+
+func TestHandleWithoutManifestOrPruning(t *testing.T) {
+	resp, err := Handle(exporter.Request{
+		Values:  map[string][]byte{"token": []byte("t")},
+		Options: []byte("manifest: \"\"\nprune: false\n"),
+	})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if resp.Prune || len(resp.Files) != 1 || resp.Files[0].Path != "token" || resp.Files[0].Mode != 0 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestHandleRejectsUnsafeLayouts(t *testing.T) {
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+	values := map[string]string{"db_password": "p", "manifest": "m"}
+
+	p.ExpectError(values, map[string]any{"key_template": `{{ .Key | replace "_" "/../" }}`}, `is not a valid file path`)
+	p.ExpectError(values, map[string]any{"directories": map[string]any{"db_": "../db"}}, `directories["db_"]`)
+	p.ExpectError(values, map[string]any{"directories": map[string]any{"db_password": "db"}}, `under directory prefix "db_password"`)
+	p.ExpectError(values, map[string]any{"manifest": "manifest"}, `secret "manifest" is written to "manifest", the manifest path`)
+	p.ExpectError(values, map[string]any{"modes": map[string]any{"db_password": 0o1777}}, `modes["db_password"]`)
+}
+
+func TestServesFilesOverPluginProtocol(t *testing.T) {
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+
+	files, err := p.Files(map[string]string{"token": "t"}, map[string]any{"sort": "config"})
+	if err != nil {
+		t.Fatalf("Files returned error: %v", err)
+	}
+	if len(files) != 2 || files[0].Path != "token" || files[1].Path != "manifest.json" {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/fr0stylo/sfx/plugins/exporters/files
+
+go 1.25.1
+
+require (
+	github.com/kr/pretty v0.3.1 // indirect
+	google.golang.org/protobuf v1.36.10 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+require github.com/fr0stylo/sfx v0.0.0-20251021203845-8800531983ec
+
+replace github.com/fr0stylo/sfx => ../../..
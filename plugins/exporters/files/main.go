@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/plugins/exporters/files/files"
+)
+
+func main() {
+	exporter.Run(exporter.HandlerFunc(files.Handle), files.Options()...)
+}
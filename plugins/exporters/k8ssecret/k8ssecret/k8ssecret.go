@@ -0,0 +1,119 @@
+// Package k8ssecret renders secrets as a Kubernetes Secret manifest.
+package k8ssecret
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+type options struct {
+	keys.Options `yaml:",inline"`
+
+	Name        string            `yaml:"name" required:"true" desc:"Secret name"`
+	Namespace   string            `yaml:"namespace" desc:"Secret namespace"`
+	Type        string            `yaml:"type" desc:"Secret type (e.g. Opaque)"`
+	Labels      map[string]string `yaml:"labels" desc:"Labels added to the manifest"`
+	Annotations map[string]string `yaml:"annotations" desc:"Annotations added to the manifest"`
+	Metadata    []string          `yaml:"metadata" desc:"Metadata fields added as <metadata_prefix>/<field>.<key> annotations, e.g. version or ref"`
+	MetaPrefix  string            `yaml:"metadata_prefix" default:"sfx" desc:"Annotation prefix for metadata fields"`
+}
+
+type secretManifest struct {
+	APIVersion string     `yaml:"apiVersion"`
+	Kind       string     `yaml:"kind"`
+	Metadata   metadata   `yaml:"metadata"`
+	Type       string     `yaml:"type,omitempty"`
+	Data       *yaml.Node `yaml:"data"`
+}
+
+type metadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Options returns the handshake options describing the exporter.
+func Options() []exporter.Option {
+	return []exporter.Option{
+		exporter.WithOptions(options{}),
+	}
+}
+
+// Handle serves a single exporter request.
+func Handle(req exporter.Request) (exporter.Response, error) {
+	opts, err := exporter.DecodeOptions[options](req.Options)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	ks, err := opts.Apply(req, keys.K8sDataKey)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+	annotations, err := metadataAnnotations(ks, opts)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+	for i := range ks {
+		ks[i].Value = []byte(base64.StdEncoding.EncodeToString(ks[i].Value))
+	}
+
+	manifest := secretManifest{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: metadata{
+			Name:        opts.Name,
+			Namespace:   opts.Namespace,
+			Labels:      opts.Labels,
+			Annotations: annotations,
+		},
+		Type: opts.Type,
+		Data: keys.Mapping(ks),
+	}
+
+	payload, err := yaml.Marshal(manifest)
+	if err != nil {
+		return exporter.Response{}, fmt.Errorf("marshal secret yaml: %w", err)
+	}
+
+	return exporter.Response{Payload: payload}, nil
+}
+
+var annotationName = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
+// metadataAnnotations merges the selected metadata fields of each key into the
+// configured annotations, which win on conflict.
+func metadataAnnotations(ks []keys.Key, opts options) (map[string]string, error) {
+	if len(opts.Metadata) == 0 {
+		return opts.Annotations, nil
+	}
+
+	out := make(map[string]string, len(opts.Annotations))
+	for _, k := range ks {
+		for _, field := range opts.Metadata {
+			v := k.Metadata[field]
+			if v == "" {
+				continue
+			}
+			name := field + "." + k.Name
+			if len(name) > 63 || !annotationName.MatchString(name) {
+				return nil, fmt.Errorf("secret %q: %q is not a valid annotation name for metadata field %q", k.Secret, name, field)
+			}
+			out[opts.MetaPrefix+"/"+name] = v
+		}
+	}
+	for k, v := range opts.Annotations {
+		out[k] = v
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
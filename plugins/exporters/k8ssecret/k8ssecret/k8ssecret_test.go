@@ -1,7 +1,8 @@
-package main
+package k8ssecret
 
 import (
 	"encoding/base64"
+	"reflect"
 	"testing"
 
 	"gopkg.in/yaml.v3"
@@ -30,12 +31,15 @@ func TestHandleK8sSecretSuccess(t *testing.T) {
 		Options: optsBytes,
 	}
 
-	resp, err := handle(req)
+	resp, err := Handle(req)
 	if err != nil {
-		t.Fatalf("handle returned error: %v", err)
+		t.Fatalf("Handle returned error: %v", err)
 	}
 
-	var manifest secretManifest
+	var manifest struct {
+		Metadata metadata          `yaml:"metadata"`
+		Data     map[string]string `yaml:"data"`
+	}
 	if err := yaml.Unmarshal(resp.Payload, &manifest); err != nil {
 		t.Fatalf("unmarshal manifest: %v", err)
 	}
@@ -57,8 +61,35 @@ func TestHandleK8sSecretSuccess(t *testing.T) {
 }
 
 func TestHandleK8sSecretMissingName(t *testing.T) {
-	_, err := handle(exporter.Request{})
+	_, err := Handle(exporter.Request{})
 	if err == nil {
 		t.Fatal("expected error for missing name")
 	}
 }
+
+func TestHandleK8sSecretMetadataAnnotations(t *testing.T) {
+	req := exporter.Request{
+		Values: map[string][]byte{"api-token": []byte("secret")},
+		Metadata: map[string]map[string]string{
+			"api-token": {exporter.MetaVersion: "7", exporter.MetaRef: "secret/app"},
+		},
+		Options: []byte("name: app\nmetadata: [version]\nannotations:\n  owner: payments\n"),
+	}
+
+	resp, err := Handle(req)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	var manifest struct {
+		Metadata metadata `yaml:"metadata"`
+	}
+	if err := yaml.Unmarshal(resp.Payload, &manifest); err != nil {
+		t.Fatalf("unmarshal manifest: %v", err)
+	}
+
+	want := map[string]string{"sfx/version.api-token": "7", "owner": "payments"}
+	if !reflect.DeepEqual(manifest.Metadata.Annotations, want) {
+		t.Fatalf("annotations = %v, want %v", manifest.Metadata.Annotations, want)
+	}
+}
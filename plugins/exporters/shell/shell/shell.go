@@ -0,0 +1,173 @@
+// Package shell renders secrets as statements that set, or unset, environment
+// variables in POSIX shells, fish, PowerShell, Nushell or csh.
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+// Modes accepted by the mode option.
+const (
+	ModeSet   = "set"
+	ModeUnset = "unset"
+)
+
+type options struct {
+	keys.Options `yaml:",inline"`
+	Shebang      string   `yaml:"shebang" desc:"Shebang line (default: the interpreter of export_format, e.g. #!/usr/bin/env bash)"`
+	Header       []string `yaml:"header" desc:"Comment lines written after the shebang"`
+	ExportFormat string   `yaml:"export_format" default:"export" desc:"export, assign, fish, powershell, nushell or csh"`
+	Mode         string   `yaml:"mode" default:"set" desc:"set to set the variables, unset to write the script that removes them"`
+}
+
+// target is the syntax of one shell.
+type target struct {
+	shebang string
+	set     func(name, value string) string
+	unset   func(name string) string
+}
+
+var targets = map[string]target{
+	"export": {
+		shebang: "#!/usr/bin/env bash",
+		set:     func(n, v string) string { return "export " + n + "=" + shellQuote(v) },
+		unset:   func(n string) string { return "unset " + n },
+	},
+	"assign": {
+		shebang: "#!/usr/bin/env bash",
+		set:     func(n, v string) string { return n + "=" + shellQuote(v) },
+		unset:   func(n string) string { return "unset " + n },
+	},
+	"fish": {
+		shebang: "#!/usr/bin/env fish",
+		set:     func(n, v string) string { return "set -gx " + n + " " + fishQuote(v) },
+		unset:   func(n string) string { return "set -e " + n },
+	},
+	"powershell": {
+		shebang: "#!/usr/bin/env pwsh",
+		set:     func(n, v string) string { return "$env:" + n + " = " + powershellQuote(v) },
+		unset:   func(n string) string { return "Remove-Item Env:" + n + " -ErrorAction SilentlyContinue" },
+	},
+	"nushell": {
+		shebang: "#!/usr/bin/env nu",
+		set:     func(n, v string) string { return "$env." + n + " = " + nushellQuote(v) },
+		unset:   func(n string) string { return "hide-env -i " + n },
+	},
+	"csh": {
+		shebang: "#!/bin/csh -f",
+		set:     func(n, v string) string { return "setenv " + n + " " + cshQuote(v) },
+		unset:   func(n string) string { return "unsetenv " + n },
+	},
+}
+
+// Options returns the handshake options describing the exporter.
+func Options() []exporter.Option {
+	return []exporter.Option{
+		exporter.WithOptions(options{}),
+	}
+}
+
+// Handle serves a single exporter request.
+func Handle(req exporter.Request) (exporter.Response, error) {
+	opts, err := exporter.DecodeOptions[options](req.Options)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	t, ok := targets[opts.ExportFormat]
+	if !ok {
+		return exporter.Response{}, fmt.Errorf("unknown export_format %q (use export, assign, fish, powershell, nushell or csh)", opts.ExportFormat)
+	}
+	switch opts.Mode {
+	case "", ModeSet, ModeUnset:
+	default:
+		return exporter.Response{}, fmt.Errorf("unknown mode %q (use %s or %s)", opts.Mode, ModeSet, ModeUnset)
+	}
+
+	ks, err := opts.Apply(req, keys.EnvVar)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	shebang := opts.Shebang
+	if shebang == "" {
+		shebang = t.shebang
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(shebang)
+	buf.WriteByte('\n')
+	if len(opts.Header) > 0 {
+		for _, line := range opts.Header {
+			buf.WriteString("# ")
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+		buf.WriteByte('\n')
+	}
+
+	for _, k := range ks {
+		if opts.Mode == ModeUnset {
+			buf.WriteString(t.unset(k.Name))
+		} else {
+			if bytes.IndexByte(k.Value, 0) >= 0 {
+				return exporter.Response{}, fmt.Errorf("secret %q: environment variables cannot hold NUL bytes", k.Secret)
+			}
+			buf.WriteString(t.set(k.Name, string(k.Value)))
+		}
+		buf.WriteByte('\n')
+	}
+
+	return exporter.Response{Payload: buf.Bytes()}, nil
+}
+
+// plainValue matches values POSIX shells read unquoted.
+var plainValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]+$`)
+
+// shellQuote single-quotes s for POSIX shells, closing the quotes around each
+// single quote. Values made only of characters without a meaning to the shell
+// are left as they are.
+func shellQuote(s string) string {
+	if plainValue.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes s for fish, where only \\ and \' are escapes
+// inside single quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// powershellQuote single-quotes s for PowerShell, doubling single quotes,
+// including the typographic ones PowerShell also accepts as quotes.
+func powershellQuote(s string) string {
+	return "'" + strings.NewReplacer("'", "''", "\u2018", "\u2018\u2018", "\u2019", "\u2019\u2019", "\u201a", "\u201a\u201a", "\u201b", "\u201b\u201b").Replace(s) + "'"
+}
+
+// nushellQuote single-quotes s for Nushell, which has no escapes inside single
+// quotes. Values containing a single quote become raw strings, r#'...'#, with
+// as many # as needed to keep the closing delimiter out of the value.
+func nushellQuote(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	hashes := "#"
+	for strings.Contains(s, "'"+hashes) {
+		hashes += "#"
+	}
+	return "r" + hashes + "'" + s + "'" + hashes
+}
+
+// cshQuote single-quotes s for csh, where history substitution (!) and
+// newlines need a backslash even inside single quotes.
+func cshQuote(s string) string {
+	return "'" + strings.NewReplacer("'", `'\''`, "!", `\!`, "\n", "\\\n").Replace(s) + "'"
+}
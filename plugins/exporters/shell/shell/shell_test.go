@@ -0,0 +1,160 @@
+package shell
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/exportertest"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+func TestHandleShellDefault(t *testing.T) {
+	req := exporter.Request{
+		Values: map[string][]byte{
+			"API_TOKEN": []byte("secret value"),
+			"EMPTY":     []byte(""),
+		},
+	}
+
+	resp, err := Handle(req)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(resp.Payload)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected shebang + 2 lines, got %d: %v", len(lines), lines)
+	}
+	if lines[0] != "#!/usr/bin/env bash" {
+		t.Fatalf("unexpected shebang: %q", lines[0])
+	}
+	if lines[1] != "export API_TOKEN='secret value'" {
+		t.Fatalf("unexpected first export line: %q", lines[1])
+	}
+	if lines[2] != "export EMPTY=''" {
+		t.Fatalf("unexpected second export line: %q", lines[2])
+	}
+}
+
+func TestHandleShellCustomOptions(t *testing.T) {
+	opts := options{
+		Shebang:      "#!/bin/sh",
+		Header:       []string{"Managed by sfx"},
+		ExportFormat: "assign",
+		Options:      keys.Options{Order: []string{"B", "A"}},
+	}
+	optsBytes, err := yaml.Marshal(opts)
+	if err != nil {
+		t.Fatalf("marshal options: %v", err)
+	}
+
+	req := exporter.Request{
+		Values: map[string][]byte{
+			"A": []byte("1"),
+			"B": []byte("2"),
+		},
+		Options: optsBytes,
+	}
+
+	resp, err := Handle(req)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	payload := string(resp.Payload)
+	if !strings.HasPrefix(payload, "#!/bin/sh\n# Managed by sfx") {
+		t.Fatalf("unexpected header: %q", payload)
+	}
+	first := strings.Index(payload, "B=")
+	second := strings.Index(payload, "A=")
+	if first == -1 || second == -1 || first > second {
+		t.Fatalf("expected B assignment before A, got %q", payload)
+	}
+}
+
+func TestHandleShellTargets(t *testing.T) {
+	values := map[string]string{"A": `it's $x \ !`, "B": "l1\nl2"}
+	tests := []struct {
+		format string
+		set    string
+		unset  string
+	}{
+		{"export", "#!/usr/bin/env bash\nexport A='it'\\''s $x \\ !'\nexport B='l1\nl2'\n", "#!/usr/bin/env bash\nunset A\nunset B\n"},
+		{"fish", "#!/usr/bin/env fish\nset -gx A 'it\\'s $x \\\\ !'\nset -gx B 'l1\nl2'\n", "#!/usr/bin/env fish\nset -e A\nset -e B\n"},
+		{"powershell", "#!/usr/bin/env pwsh\n$env:A = 'it''s $x \\ !'\n$env:B = 'l1\nl2'\n", "#!/usr/bin/env pwsh\nRemove-Item Env:A -ErrorAction SilentlyContinue\nRemove-Item Env:B -ErrorAction SilentlyContinue\n"},
+		{"nushell", "#!/usr/bin/env nu\n$env.A = r#'it's $x \\ !'#\n$env.B = 'l1\nl2'\n", "#!/usr/bin/env nu\nhide-env -i A\nhide-env -i B\n"},
+		{"csh", "#!/bin/csh -f\nsetenv A 'it'\\''s $x \\ \\!'\nsetenv B 'l1\\\nl2'\n", "#!/bin/csh -f\nunsetenv A\nunsetenv B\n"},
+	}
+
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			p.ExpectPayload(values, map[string]any{"export_format": tt.format}, tt.set)
+			p.ExpectPayload(values, map[string]any{"export_format": tt.format, "mode": ModeUnset}, tt.unset)
+		})
+	}
+}
+
+func TestShellQuotesForTrickyValues(t *testing.T) {
+	if got, want := nushellQuote(`a'#b`), `r##'a'#b'##`; got != want {
+		t.Fatalf("nushellQuote = %s, want %s", got, want)
+	}
+	if got, want := powershellQuote("it’s"), "'it’’s'"; got != want {
+		t.Fatalf("powershellQuote = %s, want %s", got, want)
+	}
+}
+
+func TestShellQuotesMetacharacters(t *testing.T) {
+	quotes := map[string]func(string) string{
+		"export":     shellQuote,
+		"fish":       fishQuote,
+		"powershell": powershellQuote,
+		"nushell":    nushellQuote,
+		"csh":        cshQuote,
+	}
+	values := []string{"abc&<def", "a;b", "a|b", "a>b", "(x)", "*", "a?", "[ab]", "#c", "~root", "a b", "{a,b}", "a^b"}
+
+	for format, quote := range quotes {
+		t.Run(format, func(t *testing.T) {
+			for _, v := range values {
+				if got, want := quote(v), "'"+v+"'"; got != want {
+					t.Fatalf("quote(%q) = %s, want %s", v, got, want)
+				}
+			}
+		})
+	}
+
+	if got, want := shellQuote("v1.2:3@x+y,z=w%"), "v1.2:3@x+y,z=w%"; got != want {
+		t.Fatalf("shellQuote of a plain value = %s, want %s", got, want)
+	}
+}
+
+func TestShellQuoteRoundTripsThroughSh(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not installed")
+	}
+
+	for _, v := range []string{"abc&<def", "a;b|c>d", "$(id) `id`", "*?[a]#~", "it's \\ \"x\"", "l1\nl2", "", "plain-1.2"} {
+		script := "export V=" + shellQuote(v) + "\nprintf %s \"$V\"\n"
+		out, err := exec.Command(sh, "-c", script).Output()
+		if err != nil {
+			t.Fatalf("sh failed for %q: %v", v, err)
+		}
+		if string(out) != v {
+			t.Fatalf("sh read %q back as %q", v, out)
+		}
+	}
+}
+
+func TestHandleShellRejectsUnknownSettings(t *testing.T) {
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+
+	p.ExpectError(map[string]string{"A": "1"}, map[string]any{"export_format": "zsh"}, `unknown export_format "zsh"`)
+	p.ExpectError(map[string]string{"A": "1"}, map[string]any{"mode": "clear"}, `unknown mode "clear"`)
+	p.ExpectError(map[string]string{"a-b": "1"}, nil, "not a valid environment variable name")
+}
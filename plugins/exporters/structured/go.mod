@@ -0,0 +1,17 @@
+module github.com/fr0stylo/sfx/plugins/exporters/structured
+
+go 1.25.1
+
+require (
+	github.com/pelletier/go-toml/v2 v2.2.4
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/kr/pretty v0.3.1 // indirect
+	google.golang.org/protobuf v1.36.10 // indirect
+)
+
+require github.com/fr0stylo/sfx v0.0.0-20251021203845-8800531983ec
+
+replace github.com/fr0stylo/sfx => ../../..
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/plugins/exporters/structured/structured"
+)
+
+func main() {
+	exporter.Run(exporter.HandlerFunc(structured.Handle), structured.Options()...)
+}
@@ -0,0 +1,276 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+func encodeJSON(root *object) ([]byte, error) {
+	var compact bytes.Buffer
+	if err := writeJSON(&compact, root); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return nil, fmt.Errorf("indent json: %w", err)
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// writeJSON writes o compactly with its fields in order; encoding/json would
+// sort them.
+func writeJSON(b *bytes.Buffer, o *object) error {
+	b.WriteByte('{')
+	for i, name := range o.names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if err := writeJSONValue(b, name); err != nil {
+			return err
+		}
+		b.WriteByte(':')
+
+		f := o.fields[name]
+		if f.obj != nil {
+			if err := writeJSON(b, f.obj); err != nil {
+				return err
+			}
+			continue
+		}
+		// Binary values are []byte, which encoding/json writes as base64.
+		v, err := f.key.Parsed()
+		if err != nil {
+			return err
+		}
+		if err := writeJSONValue(b, v); err != nil {
+			return fmt.Errorf("secret %q: encode value: %w", f.key.Secret, err)
+		}
+	}
+	b.WriteByte('}')
+	return nil
+}
+
+func writeJSONValue(b *bytes.Buffer, v any) error {
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	// Encode ends the value with a newline.
+	b.Truncate(b.Len() - 1)
+	return nil
+}
+
+func encodeYAML(root *object) ([]byte, error) {
+	node, err := yamlNode(root)
+	if err != nil {
+		return nil, err
+	}
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("marshal yaml: %w", err)
+	}
+	return out, nil
+}
+
+func yamlNode(o *object) (*yaml.Node, error) {
+	m := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range o.names {
+		f := o.fields[name]
+		var (
+			value *yaml.Node
+			err   error
+		)
+		if f.obj != nil {
+			value, err = yamlNode(f.obj)
+		} else {
+			value, err = keys.TypedNode(*f.key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+	}
+	return m, nil
+}
+
+func encodeTOML(root *object) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeTOML(&b, root, nil); err != nil {
+		return nil, err
+	}
+	return bytes.TrimPrefix(b.Bytes(), []byte("\n")), nil
+}
+
+// writeTOML writes the values of o, in order, followed by its nested objects
+// as tables: TOML requires a table's values to come before its subtables.
+func writeTOML(b *bytes.Buffer, o *object, path []string) error {
+	for _, name := range o.names {
+		f := o.fields[name]
+		if f.key == nil {
+			continue
+		}
+		v, err := tomlValue(*f.key)
+		if err != nil {
+			return err
+		}
+		// Inline tables keep JSON documents on the line of their key.
+		if err := toml.NewEncoder(b).SetTablesInline(true).Encode(map[string]any{name: v}); err != nil {
+			return fmt.Errorf("secret %q: encode value: %w", f.key.Secret, err)
+		}
+	}
+
+	for _, name := range o.names {
+		f := o.fields[name]
+		if f.obj == nil {
+			continue
+		}
+		table := append(path[:len(path):len(path)], name)
+		quoted := make([]string, len(table))
+		for i, part := range table {
+			quoted[i] = tomlKey(part)
+		}
+		b.WriteString("\n[" + strings.Join(quoted, ".") + "]\n")
+		if err := writeTOML(b, f.obj, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tomlValue(k keys.Key) (any, error) {
+	v, err := k.Parsed()
+	switch {
+	case err != nil:
+		return nil, err
+	case v == nil:
+		return nil, fmt.Errorf("secret %q: TOML has no null value", k.Secret)
+	}
+	if raw, ok := v.([]byte); ok {
+		return base64.StdEncoding.EncodeToString(raw), nil
+	}
+	return v, nil
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey quotes name unless it is a bare key. JSON string escapes are valid
+// in TOML basic strings.
+func tomlKey(name string) string {
+	if bareKey.MatchString(name) {
+		return name
+	}
+	var b bytes.Buffer
+	_ = writeJSONValue(&b, name)
+	return b.String()
+}
+
+func encodeProperties(root *object) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeProperties(&b, root, ""); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// writeProperties writes the values of o in order, joining nested keys with
+// dots as Spring and other properties readers expect.
+func writeProperties(b *bytes.Buffer, o *object, prefix string) error {
+	for _, name := range o.names {
+		f := o.fields[name]
+		if f.obj != nil {
+			if err := writeProperties(b, f.obj, prefix+name+"."); err != nil {
+				return err
+			}
+			continue
+		}
+		v, err := propertyValue(*f.key)
+		if err != nil {
+			return err
+		}
+		b.WriteString(escapeProperty(prefix+name, true))
+		b.WriteByte('=')
+		b.WriteString(escapeProperty(v, false))
+		b.WriteByte('\n')
+	}
+	return nil
+}
+
+// propertyValue returns the value of k as text: properties have no types, so
+// binary values are base64-encoded and JSON documents written compactly.
+func propertyValue(k keys.Key) (string, error) {
+	v, err := k.Parsed()
+	if err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), nil
+	default:
+		var b bytes.Buffer
+		if err := json.Compact(&b, k.Value); err != nil {
+			return "", fmt.Errorf("secret %q: compact json: %w", k.Secret, err)
+		}
+		return b.String(), nil
+	}
+}
+
+// escapeProperty escapes s as java.util.Properties.store does: separators,
+// comment characters and backslashes get a backslash, spaces are escaped in
+// keys and at the start of values, and characters outside printable ASCII are
+// written as \uXXXX.
+func escapeProperty(s string, key bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '=', ':', '#', '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case ' ':
+			if key || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(' ')
+		default:
+			if r >= 0x20 && r <= 0x7e {
+				b.WriteRune(r)
+				continue
+			}
+			if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+				fmt.Fprintf(&b, `\u%04X\u%04X`, r1, r2)
+				continue
+			}
+			fmt.Fprintf(&b, `\u%04X`, r)
+		}
+	}
+	return b.String()
+}
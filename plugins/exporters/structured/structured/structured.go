@@ -0,0 +1,157 @@
+// Package structured renders secrets as a JSON, YAML or TOML document or a Java
+// properties file, optionally nesting keys at a delimiter.
+package structured
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+// Formats accepted by the format option.
+const (
+	FormatJSON       = "json"
+	FormatYAML       = "yaml"
+	FormatTOML       = "toml"
+	FormatProperties = "properties"
+)
+
+type options struct {
+	keys.Options `yaml:",inline"`
+
+	Format    string `yaml:"format" default:"json" desc:"Output format: json, yaml, toml or properties"`
+	Delimiter string `yaml:"delimiter" desc:"Nest keys at this delimiter, e.g. __ turns DB__HOST into db.host; key_case then applies to each part"`
+}
+
+// Options returns the handshake options describing the exporter.
+func Options() []exporter.Option {
+	return []exporter.Option{
+		exporter.WithOptions(options{}),
+	}
+}
+
+// Handle serves a single exporter request.
+func Handle(req exporter.Request) (exporter.Response, error) {
+	opts, err := exporter.DecodeOptions[options](req.Options)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	var encode func(*object) ([]byte, error)
+	switch opts.Format {
+	case FormatJSON:
+		encode = encodeJSON
+	case FormatYAML:
+		encode = encodeYAML
+	case FormatTOML:
+		encode = encodeTOML
+	case FormatProperties:
+		encode = encodeProperties
+	default:
+		return exporter.Response{}, fmt.Errorf("unknown format %q (use %s, %s, %s or %s)", opts.Format, FormatJSON, FormatYAML, FormatTOML, FormatProperties)
+	}
+
+	root, err := build(req, opts)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+	payload, err := encode(root)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+	return exporter.Response{Payload: payload}, nil
+}
+
+// object is a table of the output document whose fields keep the order in
+// which they were added.
+type object struct {
+	names  []string
+	fields map[string]*field
+	// owner is the first secret nested under the object, for errors.
+	owner string
+}
+
+// field is either a value, holding the secret's key, or a nested object.
+type field struct {
+	key *keys.Key
+	obj *object
+}
+
+func newObject(owner string) *object {
+	return &object{fields: map[string]*field{}, owner: owner}
+}
+
+func (o *object) add(name string, f *field) {
+	o.names = append(o.names, name)
+	o.fields[name] = f
+}
+
+// build orders and names the secrets with the key options and arranges them
+// into nested objects at the delimiter.
+func build(req exporter.Request, opts options) (*object, error) {
+	convert, err := keys.Case(opts.KeyCase)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Delimiter != "" {
+		// The case is applied to each part below, so it cannot merge them.
+		opts.KeyCase = ""
+	}
+
+	ks, err := opts.Apply(req, keys.Any)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := make(map[string]bool, len(opts.Rename))
+	for secret := range opts.Rename {
+		renamed[strings.ToLower(secret)] = true
+	}
+
+	root := newObject("")
+	for i := range ks {
+		k := &ks[i]
+		path := []string{k.Name}
+		if opts.Delimiter != "" {
+			path = strings.Split(k.Name, opts.Delimiter)
+			for j, part := range path {
+				if part == "" {
+					return nil, fmt.Errorf("secret %q: key %q has an empty part when split at %q", k.Secret, k.Name, opts.Delimiter)
+				}
+				if !renamed[strings.ToLower(k.Secret)] {
+					path[j] = convert(part)
+				}
+			}
+		}
+		if err := root.insert(path, k); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+func (o *object) insert(path []string, k *keys.Key) error {
+	at := strings.Join(path, ".")
+	for i, name := range path {
+		f, ok := o.fields[name]
+		last := i == len(path)-1
+		switch {
+		case !ok && last:
+			o.add(name, &field{key: k})
+			return nil
+		case !ok:
+			f = &field{obj: newObject(k.Secret)}
+			o.add(name, f)
+		case f.key != nil && last:
+			return fmt.Errorf("secrets %q and %q are both exported as %q", f.key.Secret, k.Secret, at)
+		case f.key != nil:
+			return fmt.Errorf("secrets %q and %q conflict: %q is a value, so %q cannot nest under it", f.key.Secret, k.Secret, strings.Join(path[:i+1], "."), at)
+		case last:
+			return fmt.Errorf("secrets %q and %q conflict: %q holds nested keys, so it cannot be a value", f.obj.owner, k.Secret, at)
+		}
+		o = f.obj
+	}
+	return nil
+}
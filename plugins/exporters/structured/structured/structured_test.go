@@ -0,0 +1,119 @@
+package structured
+
+import (
+	"testing"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/exportertest"
+)
+
+var request = exporter.Request{
+	Values: map[string][]byte{
+		"APP_NAME":        []byte("payments"),
+		"DB__HOST":        []byte("db.internal"),
+		"DB__PORT":        []byte("5432"),
+		"DB__MAX_POOL":    []byte("10"),
+		"FEATURES__FLAGS": []byte(`{"beta":true,"id":9007199254740993,"tiers":["a","b"]}`),
+		"DEBUG":           []byte("false"),
+	},
+	Keys:  []string{"APP_NAME", "DB__HOST", "DB__PORT", "DB__MAX_POOL", "FEATURES__FLAGS", "DEBUG"},
+	Types: map[string]string{"DB__PORT": exporter.TypeInt, "DEBUG": exporter.TypeBool, "FEATURES__FLAGS": exporter.TypeJSON},
+}
+
+func TestHandleFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: FormatJSON, want: `{
+  "appName": "payments",
+  "db": {
+    "host": "db.internal",
+    "port": 5432,
+    "maxPool": "10"
+  },
+  "features": {
+    "flags": {
+      "beta": true,
+      "id": 9007199254740993,
+      "tiers": [
+        "a",
+        "b"
+      ]
+    }
+  },
+  "debug": false
+}
+`},
+		{format: FormatYAML, want: `appName: payments
+db:
+    host: db.internal
+    port: 5432
+    maxPool: "10"
+features:
+    flags:
+        beta: true
+        id: 9007199254740993
+        tiers:
+            - a
+            - b
+debug: false
+`},
+		{format: FormatTOML, want: `appName = 'payments'
+debug = false
+
+[db]
+host = 'db.internal'
+port = 5432
+maxPool = '10'
+
+[features]
+flags = {beta = true, id = 9007199254740993, tiers = ['a', 'b']}
+`},
+		{format: FormatProperties, want: `appName=payments
+db.host=db.internal
+db.port=5432
+db.maxPool=10
+features.flags={"beta"\:true,"id"\:9007199254740993,"tiers"\:["a","b"]}
+debug=false
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			req := request
+			req.Options = []byte("format: " + tt.format + "\nsort: config\ndelimiter: __\nkey_case: camel\n")
+			resp, err := Handle(req)
+			if err != nil {
+				t.Fatalf("Handle returned error: %v", err)
+			}
+			if string(resp.Payload) != tt.want {
+				t.Fatalf("payload =\n%s\nwant\n%s", resp.Payload, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleFlatKeysAreSortedByDefault(t *testing.T) {
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+	p.ExpectPayload(map[string]string{"zone": "eu", "api_key": "k"}, nil, "{\n  \"api_key\": \"k\",\n  \"zone\": \"eu\"\n}\n")
+	p.ExpectPayload(map[string]string{"db__host": "h"}, map[string]any{"format": "yaml"}, "db__host: h\n")
+}
+
+func TestEscapeProperty(t *testing.T) {
+	if got, want := escapeProperty("a key=1", true), `a\ key\=1`; got != want {
+		t.Fatalf("key = %s, want %s", got, want)
+	}
+	if got, want := escapeProperty(" p#ss\nwörd 😀", false), `\ p\#ss\nw\u00F6rd \uD83D\uDE00`; got != want {
+		t.Fatalf("value = %s, want %s", got, want)
+	}
+}
+
+func TestHandleRejectsConflictsAndUnknownFormats(t *testing.T) {
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+
+	p.ExpectError(map[string]string{"db": "x", "db__host": "h"}, map[string]any{"delimiter": "__"}, `"db" is a value, so "db.host" cannot nest under it`)
+	p.ExpectError(map[string]string{"a__b": "x", "a": "y"}, map[string]any{"delimiter": "__", "order": []string{"a__b"}}, `"a" holds nested keys`)
+	p.ExpectError(map[string]string{"db__": "x"}, map[string]any{"delimiter": "__"}, `empty part`)
+	p.ExpectError(map[string]string{"a": "x"}, map[string]any{"format": "ini"}, `unknown format "ini"`)
+}
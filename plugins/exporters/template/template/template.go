@@ -0,0 +1,179 @@
+// Package template renders secrets through a user-supplied Go template.
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+type options struct {
+	keys.Options `yaml:",inline"`
+
+	Template     string `yaml:"template" desc:"Inline Go template"`
+	TemplatePath string `yaml:"template_path" desc:"Path to a Go template file"`
+	Delims       struct {
+		Left  string `yaml:"left" desc:"Left action delimiter"`
+		Right string `yaml:"right" desc:"Right action delimiter"`
+	} `yaml:"delims" desc:"Custom template delimiters"`
+	Templates []fileTemplate `yaml:"templates" desc:"Templates rendered to files under the output directory"`
+}
+
+type fileTemplate struct {
+	Path         string      `yaml:"path" desc:"Output file, relative to the output directory"`
+	Template     string      `yaml:"template" desc:"Inline Go template"`
+	TemplatePath string      `yaml:"template_path" desc:"Path to a Go template file"`
+	Mode         fs.FileMode `yaml:"mode" desc:"File permissions, e.g. 0640 (default 0600)"`
+}
+
+// Options returns the handshake options describing the exporter.
+func Options() []exporter.Option {
+	return []exporter.Option{
+		exporter.WithOptions(options{}),
+	}
+}
+
+// Handle serves a single exporter request. template or template_path renders
+// the payload; each entry of templates renders one file.
+func Handle(req exporter.Request) (exporter.Response, error) {
+	opts, err := exporter.DecodeOptions[options](req.Options)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	single := opts.Template != "" || opts.TemplatePath != ""
+	if !single && len(opts.Templates) == 0 {
+		return exporter.Response{}, fmt.Errorf("template content not provided (set template, template_path or templates)")
+	}
+	if (opts.Delims.Left == "") != (opts.Delims.Right == "") {
+		return exporter.Response{}, fmt.Errorf("both delims.left and delims.right must be set")
+	}
+
+	funcMap := sprig.TxtFuncMap()
+	opts.Funcs = funcMap
+	ks, err := opts.Apply(req, keys.Any)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	typedMap, err := typedValues(ks)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	data := map[string]any{
+		"Values":   stringMap(ks),
+		"Raw":      rawMap(ks),
+		"Typed":    typedMap,
+		"Keys":     names(ks),
+		"Metadata": metadataMap(ks),
+	}
+	r := renderer{funcs: funcMap, left: opts.Delims.Left, right: opts.Delims.Right, data: data}
+
+	var resp exporter.Response
+	if single {
+		if resp.Payload, err = r.render("export", opts.Template, opts.TemplatePath); err != nil {
+			return exporter.Response{}, err
+		}
+	}
+
+	for i, ft := range opts.Templates {
+		if ft.Path == "" {
+			return exporter.Response{}, fmt.Errorf("templates[%d]: path is required", i)
+		}
+		if ft.Template == "" && ft.TemplatePath == "" {
+			return exporter.Response{}, fmt.Errorf("templates[%d] (%s): set template or template_path", i, ft.Path)
+		}
+		content, err := r.render(ft.Path, ft.Template, ft.TemplatePath)
+		if err != nil {
+			return exporter.Response{}, fmt.Errorf("templates[%d] (%s): %w", i, ft.Path, err)
+		}
+		resp.Files = append(resp.Files, exporter.File{Path: ft.Path, Content: content, Mode: ft.Mode})
+	}
+
+	return resp, nil
+}
+
+// renderer executes templates against the same data, functions and delimiters.
+type renderer struct {
+	funcs       template.FuncMap
+	left, right string
+	data        any
+}
+
+// render executes the inline template source, or the template read from path
+// when source is empty.
+func (r renderer) render(name, source, path string) ([]byte, error) {
+	if source == "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read template file: %w", err)
+		}
+		source = string(raw)
+	}
+
+	tmpl, err := template.New(name).Funcs(r.funcs).Delims(r.left, r.right).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r.data); err != nil {
+		return nil, fmt.Errorf("execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func stringMap(ks []keys.Key) map[string]string {
+	out := make(map[string]string, len(ks))
+	for _, k := range ks {
+		out[k.Name] = string(k.Value)
+	}
+	return out
+}
+
+func rawMap(ks []keys.Key) map[string][]byte {
+	out := make(map[string][]byte, len(ks))
+	for _, k := range ks {
+		out[k.Name] = k.Value
+	}
+	return out
+}
+
+// typedValues converts each value to its declared type; see
+// exporter.ParseValue.
+func typedValues(ks []keys.Key) (map[string]any, error) {
+	out := make(map[string]any, len(ks))
+	for _, k := range ks {
+		v, err := k.Parsed()
+		if err != nil {
+			return nil, err
+		}
+		out[k.Name] = v
+	}
+	return out, nil
+}
+
+func metadataMap(ks []keys.Key) map[string]map[string]string {
+	out := make(map[string]map[string]string, len(ks))
+	for _, k := range ks {
+		out[k.Name] = k.Metadata
+	}
+	return out
+}
+
+// names lists the keys in export order; ranging over Values is always sorted.
+func names(ks []keys.Key) []string {
+	out := make([]string, len(ks))
+	for i, k := range ks {
+		out[i] = k.Name
+	}
+	return out
+}
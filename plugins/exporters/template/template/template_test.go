@@ -0,0 +1,125 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/exportertest"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+func TestHandleTemplateInline(t *testing.T) {
+	opts := options{Template: "API_TOKEN={{ index .Values \"api_token\" }}"}
+	optsBytes, err := yaml.Marshal(opts)
+	if err != nil {
+		t.Fatalf("marshal options: %v", err)
+	}
+
+	req := exporter.Request{
+		Values:  map[string][]byte{"api_token": []byte("secret")},
+		Options: optsBytes,
+	}
+
+	resp, err := Handle(req)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	if got := strings.TrimSpace(string(resp.Payload)); got != "API_TOKEN=secret" {
+		t.Fatalf("unexpected payload: %q", got)
+	}
+}
+
+func TestHandleTemplateFile(t *testing.T) {
+	dir := t.TempDir()
+	tplPath := filepath.Join(dir, "config.tmpl")
+	if err := os.WriteFile(tplPath, []byte("count={{ len .Values }}"), 0o600); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+
+	opts := options{TemplatePath: tplPath}
+	optsBytes, err := yaml.Marshal(opts)
+	if err != nil {
+		t.Fatalf("marshal options: %v", err)
+	}
+
+	req := exporter.Request{Values: map[string][]byte{"a": []byte("1"), "b": []byte("2")}, Options: optsBytes}
+
+	resp, err := Handle(req)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	if got := strings.TrimSpace(string(resp.Payload)); got != "count=2" {
+		t.Fatalf("unexpected payload: %q", got)
+	}
+}
+
+func TestHandleTemplateKeys(t *testing.T) {
+	opts := options{
+		Options:  keys.Options{Sort: keys.SortConfig, KeyCase: "upper"},
+		Template: `{{ range .Keys }}{{ . }}={{ index $.Values . }};{{ end }}`,
+	}
+	optsBytes, err := yaml.Marshal(opts)
+	if err != nil {
+		t.Fatalf("marshal options: %v", err)
+	}
+
+	req := exporter.Request{
+		Values:  map[string][]byte{"a": []byte("1"), "b": []byte("2")},
+		Keys:    []string{"b", "a"},
+		Options: optsBytes,
+	}
+
+	resp, err := Handle(req)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	if got := string(resp.Payload); got != "B=2;A=1;" {
+		t.Fatalf("unexpected payload: %q", got)
+	}
+}
+
+func TestHandleTemplateMissingContent(t *testing.T) {
+	_, err := Handle(exporter.Request{})
+	if err == nil || !strings.Contains(err.Error(), "template content not provided") {
+		t.Fatalf("expected missing template error, got %v", err)
+	}
+}
+
+func TestHandleTemplateFiles(t *testing.T) {
+	dir := t.TempDir()
+	tplPath := filepath.Join(dir, "secret.tmpl")
+	if err := os.WriteFile(tplPath, []byte("token: {{ .Values.api_token }}\n"), 0o600); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+
+	p := exportertest.Start(t, exporter.HandlerFunc(Handle), Options()...)
+	files, err := p.Files(map[string]string{"api_token": "s3cret"}, map[string]any{
+		"templates": []map[string]any{
+			{"path": "app.env", "template": "API_TOKEN={{ .Values.api_token }}\n"},
+			{"path": "k8s/secret.yaml", "template_path": tplPath, "mode": 0o640},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Files returned error: %v", err)
+	}
+
+	want := []exporter.File{
+		{Path: "app.env", Content: []byte("API_TOKEN=s3cret\n")},
+		{Path: "k8s/secret.yaml", Content: []byte("token: s3cret\n"), Mode: 0o640},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("files = %+v, want %+v", files, want)
+	}
+
+	p.ExpectError(nil, map[string]any{"templates": []map[string]any{{"template": "x"}}}, "templates[0]: path is required")
+	p.ExpectError(nil, map[string]any{"templates": []map[string]any{{"path": "a", "template": "{{"}}}, "templates[0] (a): parse template")
+}
@@ -0,0 +1,168 @@
+// Package tfvars renders secrets as a Terraform tfvars file.
+package tfvars
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/keys"
+)
+
+type options struct {
+	keys.Options `yaml:",inline"`
+}
+
+// Options returns the handshake options describing the exporter.
+func Options() []exporter.Option {
+	return []exporter.Option{
+		exporter.WithOptions(options{}),
+	}
+}
+
+// Handle serves a single exporter request.
+func Handle(req exporter.Request) (exporter.Response, error) {
+	opts, err := exporter.DecodeOptions[options](req.Options)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	ks, err := opts.Apply(req, keys.HCLIdentifier)
+	if err != nil {
+		return exporter.Response{}, err
+	}
+
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+
+	for _, k := range ks {
+		val, err := ctyValue(k)
+		if err != nil {
+			return exporter.Response{}, err
+		}
+		body.SetAttributeValue(k.Name, val)
+	}
+
+	var buf bytes.Buffer
+	if _, err := file.WriteTo(&buf); err != nil {
+		return exporter.Response{}, fmt.Errorf("render tfvars: %w", err)
+	}
+
+	// Ensure trailing newline
+	if !strings.HasSuffix(buf.String(), "\n") {
+		buf.WriteByte('\n')
+	}
+
+	return exporter.Response{Payload: buf.Bytes()}, nil
+}
+
+// ctyValue converts a value to its declared type. Values without one are
+// guessed by decodeValue.
+func ctyValue(k keys.Key) (cty.Value, error) {
+	switch k.Type {
+	case "":
+		return decodeValue(k.Value), nil
+	case exporter.TypeBinary:
+		// HCL has no bytes type; use base64 as filebase64 does.
+		return cty.StringVal(base64.StdEncoding.EncodeToString(k.Value)), nil
+	}
+	v, err := k.Parsed()
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return convertToCty(v), nil
+}
+
+func decodeValue(b []byte) cty.Value {
+	if len(b) == 0 {
+		return cty.StringVal("")
+	}
+	str := strings.TrimSpace(string(b))
+
+	if v, err := strconv.ParseBool(str); err == nil {
+		return cty.BoolVal(v)
+	}
+	if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return cty.NumberIntVal(i)
+	}
+	if f, err := strconv.ParseFloat(str, 64); err == nil {
+		return cty.NumberFloatVal(f)
+	}
+
+	// Try to treat the payload as JSON to support complex structures.
+	if maybeJSON(str) {
+		if val, err := jsonToCty([]byte(str)); err == nil && val.Type() != cty.NilType {
+			return val
+		}
+	}
+
+	return cty.StringVal(string(b))
+}
+
+func maybeJSON(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	first := s[0]
+	last := s[len(s)-1]
+	switch first {
+	case '{':
+		return last == '}'
+	case '[':
+		return last == ']'
+	case '"':
+		return last == '"'
+	}
+	return false
+}
+
+func jsonToCty(b []byte) (cty.Value, error) {
+	v, err := exporter.ParseValue(exporter.TypeJSON, b)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return convertToCty(v), nil
+}
+
+func convertToCty(v any) cty.Value {
+	switch val := v.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType)
+	case bool:
+		return cty.BoolVal(val)
+	case int64:
+		return cty.NumberIntVal(val)
+	case uint64:
+		return cty.NumberUIntVal(val)
+	case json.Number:
+		if n, err := cty.ParseNumberVal(val.String()); err == nil {
+			return n
+		}
+		return cty.StringVal(val.String())
+	case float64:
+		return cty.NumberFloatVal(val)
+	case string:
+		return cty.StringVal(val)
+	case []any:
+		elems := make([]cty.Value, len(val))
+		for i, e := range val {
+			elems[i] = convertToCty(e)
+		}
+		return cty.TupleVal(elems)
+	case map[string]any:
+		result := make(map[string]cty.Value, len(val))
+		for k, e := range val {
+			result[k] = convertToCty(e)
+		}
+		return cty.ObjectVal(result)
+	default:
+		return cty.StringVal(fmt.Sprintf("%v", val))
+	}
+}
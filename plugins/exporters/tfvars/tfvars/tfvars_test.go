@@ -1,4 +1,4 @@
-package main
+package tfvars
 
 import (
 	"strings"
@@ -7,10 +7,11 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/fr0stylo/sfx/exporter"
+	"github.com/fr0stylo/sfx/exporter/keys"
 )
 
 func TestHandleTFVarsTypesAndOrder(t *testing.T) {
-	opts := options{Order: []string{"json", "number"}}
+	opts := options{Options: keys.Options{Order: []string{"json", "number"}}}
 	optsBytes, err := yaml.Marshal(opts)
 	if err != nil {
 		t.Fatalf("marshal options: %v", err)
@@ -26,9 +27,9 @@ func TestHandleTFVarsTypesAndOrder(t *testing.T) {
 		Options: optsBytes,
 	}
 
-	resp, err := handle(req)
+	resp, err := Handle(req)
 	if err != nil {
-		t.Fatalf("handle returned error: %v", err)
+		t.Fatalf("Handle returned error: %v", err)
 	}
 
 	output := string(resp.Payload)
@@ -61,3 +62,41 @@ func normalizeSpaces(s string) string {
 	}
 	return s
 }
+
+func TestHandleTFVarsHonoursDeclaredTypes(t *testing.T) {
+	req := exporter.Request{
+		Values: map[string][]byte{
+			"zip":   []byte("01234"),
+			"flag":  []byte("true"),
+			"port":  []byte("8080"),
+			"hosts": []byte(`["a","b"]`),
+			"ids":   []byte(`{"account":9007199254740993}`),
+			"blob":  {0, 1},
+		},
+		Types: map[string]string{
+			"zip":   exporter.TypeString,
+			"flag":  exporter.TypeString,
+			"port":  exporter.TypeInt,
+			"hosts": exporter.TypeJSON,
+			"ids":   exporter.TypeJSON,
+			"blob":  exporter.TypeBinary,
+		},
+	}
+
+	resp, err := Handle(req)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	normalized := normalizeSpaces(string(resp.Payload))
+	for _, want := range []string{`zip = "01234"`, `flag = "true"`, `port = 8080`, `hosts = ["a", "b"]`, `account = 9007199254740993`, `blob = "AAE="`} {
+		if !strings.Contains(normalized, want) {
+			t.Fatalf("expected %s, got:\n%s", want, resp.Payload)
+		}
+	}
+
+	req.Values["port"] = []byte("eighty")
+	if _, err := Handle(req); err == nil || !strings.Contains(err.Error(), `secret "port"`) {
+		t.Fatalf("Handle error = %v, want invalid int for port", err)
+	}
+}
@@ -0,0 +1,236 @@
+// Package awssecrets reads secrets from AWS Secrets Manager.
+package awssecrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"github.com/fr0stylo/sfx/provider"
+	"github.com/fr0stylo/sfx/provider/ref"
+)
+
+const defaultAWSSecretsTimeout = 30 * time.Second
+
+type options struct {
+	Region       string        `yaml:"region" desc:"AWS region"`
+	Profile      string        `yaml:"profile" desc:"Shared config profile"`
+	VersionID    string        `yaml:"version_id" desc:"Secret version ID"`
+	VersionStage string        `yaml:"version_stage" desc:"Secret version stage (e.g. AWSCURRENT)"`
+	Timeout      time.Duration `yaml:"timeout" default:"30s" desc:"Request timeout"`
+}
+
+// Options returns the handshake options describing the provider.
+func Options() []provider.Option {
+	return []provider.Option{
+		provider.WithRefFormat("<secret-id>[#stage:<name>|#version:<id>]"),
+		provider.WithOptions(options{}),
+	}
+}
+
+// Provider reads secrets from Secrets Manager, reusing one client per region
+// and profile across requests.
+type Provider struct {
+	clients provider.Cache[clientKey, *secretsmanager.Client]
+}
+
+type clientKey struct {
+	region  string
+	profile string
+}
+
+// New returns a Provider with an empty client cache.
+func New() *Provider {
+	return &Provider{}
+}
+
+// Handle serves a single provider request.
+func (p *Provider) Handle(ctx context.Context, req provider.Request) (provider.Response, error) {
+	l, err := parseRequest(req)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	client, err := p.client(ctx, l.key)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(l.secretID),
+	}
+	if l.versionID != "" {
+		input.VersionId = aws.String(l.versionID)
+	}
+	if l.versionStage != "" {
+		input.VersionStage = aws.String(l.versionStage)
+	}
+
+	resp, err := client.GetSecretValue(ctx, input)
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("get secret %q: %w", l.secretID, err)
+	}
+
+	md := map[string]string{}
+	if resp.VersionId != nil {
+		md[provider.MetaVersion] = *resp.VersionId
+	}
+	if resp.CreatedDate != nil {
+		md[provider.MetaCreated] = provider.FormatTime(*resp.CreatedDate)
+	}
+
+	switch {
+	case resp.SecretString != nil:
+		return provider.Response{Value: []byte(*resp.SecretString), Metadata: md}, nil
+	case len(resp.SecretBinary) > 0:
+		return provider.Response{Value: resp.SecretBinary, Metadata: md}, nil
+	default:
+		return provider.Response{}, errors.New("secret contained no data")
+	}
+}
+
+// Check confirms that the secret and the requested version or stage exist
+// with DescribeSecret, which does not return the value.
+func (p *Provider) Check(ctx context.Context, req provider.Request) error {
+	l, err := parseRequest(req)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	client, err := p.client(ctx, l.key)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(l.secretID),
+	})
+	if err != nil {
+		return fmt.Errorf("describe secret %q: %w", l.secretID, err)
+	}
+	if resp.DeletedDate != nil {
+		return fmt.Errorf("secret %q is scheduled for deletion", l.secretID)
+	}
+	return checkVersion(l, resp.VersionIdsToStages)
+}
+
+// lookup is a decoded request: the secret, the version to read and how to
+// reach it.
+type lookup struct {
+	key          clientKey
+	timeout      time.Duration
+	secretID     string
+	versionID    string
+	versionStage string
+}
+
+func parseRequest(req provider.Request) (lookup, error) {
+	opts, err := provider.DecodeOptions[options](req.Options)
+	if err != nil {
+		return lookup{}, err
+	}
+
+	secretID, versionID, versionStage, err := parseRef(req.Ref)
+	if err != nil {
+		return lookup{}, err
+	}
+	if secretID == "" {
+		return lookup{}, errors.New("ref must include the secret identifier")
+	}
+
+	l := lookup{
+		key:          clientKey{region: opts.Region, profile: opts.Profile},
+		timeout:      resolveTimeout(opts.Timeout, defaultAWSSecretsTimeout),
+		secretID:     secretID,
+		versionID:    opts.VersionID,
+		versionStage: opts.VersionStage,
+	}
+	if l.versionID == "" {
+		l.versionID = versionID
+	}
+	if l.versionStage == "" {
+		l.versionStage = versionStage
+	}
+	return l, nil
+}
+
+func (p *Provider) client(ctx context.Context, key clientKey) (*secretsmanager.Client, error) {
+	return p.clients.Get(key, func() (*secretsmanager.Client, error) {
+		cfg, err := loadConfig(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return secretsmanager.NewFromConfig(cfg), nil
+	})
+}
+
+// checkVersion reports whether versions, mapping version IDs to their stages
+// as DescribeSecret returns them, holds the version l asks for.
+func checkVersion(l lookup, versions map[string][]string) error {
+	if l.versionID != "" {
+		stages, ok := versions[l.versionID]
+		if !ok {
+			return fmt.Errorf("secret %q has no version %q", l.secretID, l.versionID)
+		}
+		if l.versionStage != "" && !slices.Contains(stages, l.versionStage) {
+			return fmt.Errorf("secret %q version %q is not labelled %q", l.secretID, l.versionID, l.versionStage)
+		}
+		return nil
+	}
+	if l.versionStage == "" {
+		return nil
+	}
+	for _, stages := range versions {
+		if slices.Contains(stages, l.versionStage) {
+			return nil
+		}
+	}
+	return fmt.Errorf("secret %q has no version labelled %q", l.secretID, l.versionStage)
+}
+
+func loadConfig(ctx context.Context, key clientKey) (aws.Config, error) {
+	var cfgOpts []func(*config.LoadOptions) error
+	if key.region != "" {
+		cfgOpts = append(cfgOpts, config.WithRegion(key.region))
+	}
+	if key.profile != "" {
+		cfgOpts = append(cfgOpts, config.WithSharedConfigProfile(key.profile))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, cfgOpts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("load aws config: %w", err)
+	}
+	return cfg, nil
+}
+
+func parseRef(s string) (secretID, versionID, versionStage string, err error) {
+	r, err := ref.Parse(s)
+	if err != nil {
+		return "", "", "", err
+	}
+	// A bare fragment is treated as a stage for convenience.
+	if r.Stage == "" {
+		r.Stage = r.Field
+	}
+	return r.Path, r.Version, r.Stage, nil
+}
+
+func resolveTimeout(given time.Duration, fallback time.Duration) time.Duration {
+	if given <= 0 {
+		return fallback
+	}
+	return given
+}
@@ -1,4 +1,4 @@
-package main
+package awssecrets
 
 import (
 	"context"
@@ -183,7 +183,8 @@ func TestParseRef(t *testing.T) {
 		tt := tt
 
 		t.Run(tt.name, func(t *testing.T) {
-			secretID, versionID, versionStage := parseRef(tt.ref)
+			secretID, versionID, versionStage, err := parseRef(tt.ref)
+			require.NoError(t, err)
 			assert.Equal(t, tt.secretID, secretID, "secret id")
 			assert.Equal(t, tt.versionID, versionID, "version id")
 			assert.Equal(t, tt.versionStage, versionStage, "version stage")
@@ -233,7 +234,7 @@ func TestResolveTimeout(t *testing.T) {
 func TestHandleRequiresSecretID(t *testing.T) {
 	t.Parallel()
 
-	_, err := handle(provider.Request{Ref: ""})
+	_, err := New().Handle(context.Background(), provider.Request{Ref: ""})
 	if assert.Error(t, err) {
 		assert.EqualError(t, err, "ref must include the secret identifier")
 	}
@@ -244,7 +245,7 @@ func TestHandleReturnsSecretString(t *testing.T) {
 	secretName := uniqueSecretName("string")
 	versionID := createSecretString(t, client, secretName, "super-secret")
 
-	resp, err := handle(provider.Request{Ref: fmt.Sprintf("%s#version:%s", secretName, versionID)})
+	resp, err := New().Handle(context.Background(), provider.Request{Ref: fmt.Sprintf("%s#version:%s", secretName, versionID)})
 	require.NoError(t, err)
 	assert.Equal(t, "super-secret", string(resp.Value))
 }
@@ -258,7 +259,7 @@ func TestHandlePrefersOptionVersionStage(t *testing.T) {
 
 	require.NotEmpty(t, prevVersion, "previous version id must not be empty")
 
-	resp, err := handle(provider.Request{
+	resp, err := New().Handle(context.Background(), provider.Request{
 		Ref:     secretName + "#stage:IGNORED",
 		Options: []byte("version_stage: AWSPREVIOUS\n"),
 	})
@@ -271,7 +272,51 @@ func TestHandleReturnsBinary(t *testing.T) {
 	secretName := uniqueSecretName("binary")
 	createSecretBinary(t, client, secretName, []byte("test"))
 
-	resp, err := handle(provider.Request{Ref: secretName})
+	resp, err := New().Handle(context.Background(), provider.Request{Ref: secretName})
 	require.NoError(t, err)
 	assert.Equal(t, "test", string(resp.Value))
 }
+
+func TestCheckVersion(t *testing.T) {
+	t.Parallel()
+
+	versions := map[string][]string{
+		"v1": {"AWSPREVIOUS"},
+		"v2": {"AWSCURRENT", "release"},
+	}
+	tests := []struct {
+		name    string
+		lookup  lookup
+		wantErr string
+	}{
+		{name: "current", lookup: lookup{secretID: "app"}},
+		{name: "version", lookup: lookup{secretID: "app", versionID: "v1"}},
+		{name: "stage", lookup: lookup{secretID: "app", versionStage: "release"}},
+		{name: "version and stage", lookup: lookup{secretID: "app", versionID: "v2", versionStage: "AWSCURRENT"}},
+		{name: "missing version", lookup: lookup{secretID: "app", versionID: "v3"}, wantErr: `secret "app" has no version "v3"`},
+		{name: "missing stage", lookup: lookup{secretID: "app", versionStage: "beta"}, wantErr: `secret "app" has no version labelled "beta"`},
+		{name: "stage on other version", lookup: lookup{secretID: "app", versionID: "v1", versionStage: "AWSCURRENT"}, wantErr: `secret "app" version "v1" is not labelled "AWSCURRENT"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkVersion(tt.lookup, versions)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCheckConfirmsSecretWithoutValue(t *testing.T) {
+	client := newLocalstackClient(t)
+	secretName := uniqueSecretName("check")
+	versionID := createSecretString(t, client, secretName, "super-secret")
+
+	p := New()
+	require.NoError(t, p.Check(context.Background(), provider.Request{Ref: fmt.Sprintf("%s#version:%s", secretName, versionID)}))
+	assert.Error(t, p.Check(context.Background(), provider.Request{Ref: secretName + "#version:missing"}))
+	assert.Error(t, p.Check(context.Background(), provider.Request{Ref: uniqueSecretName("absent")}))
+}
@@ -0,0 +1,186 @@
+// Package awsssm reads parameters from AWS Systems Manager Parameter Store.
+package awsssm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	"github.com/fr0stylo/sfx/provider"
+	"github.com/fr0stylo/sfx/provider/ref"
+)
+
+const defaultAWSSSMTimeout = 30 * time.Second
+
+type options struct {
+	Region         string        `yaml:"region" desc:"AWS region"`
+	Profile        string        `yaml:"profile" desc:"Shared config profile"`
+	WithDecryption *bool         `yaml:"with_decryption" default:"true" desc:"Decrypt SecureString parameters"`
+	Timeout        time.Duration `yaml:"timeout" default:"30s" desc:"Request timeout"`
+}
+
+// Options returns the handshake options describing the provider.
+func Options() []provider.Option {
+	return []provider.Option{
+		provider.WithRefFormat("<parameter-name>[#version:<n>|#stage:<label>]"),
+		provider.WithOptions(options{}),
+	}
+}
+
+// Provider reads parameters from Parameter Store, reusing one client per region
+// and profile across requests.
+type Provider struct {
+	clients provider.Cache[clientKey, *ssm.Client]
+}
+
+type clientKey struct {
+	region  string
+	profile string
+}
+
+// New returns a Provider with an empty client cache.
+func New() *Provider {
+	return &Provider{}
+}
+
+// Handle serves a single provider request.
+func (p *Provider) Handle(ctx context.Context, req provider.Request) (provider.Response, error) {
+	l, err := parseRequest(req)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	resp, err := p.getParameter(ctx, l, l.withDecryption)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	if resp.Parameter.Value == nil {
+		return provider.Response{}, fmt.Errorf("parameter value empty")
+	}
+
+	md := map[string]string{
+		provider.MetaVersion: strconv.FormatInt(resp.Parameter.Version, 10),
+	}
+	if resp.Parameter.LastModifiedDate != nil {
+		md[provider.MetaUpdated] = provider.FormatTime(*resp.Parameter.LastModifiedDate)
+	}
+	if resp.Parameter.DataType != nil {
+		md[provider.MetaContentType] = *resp.Parameter.DataType
+	}
+	return provider.Response{Value: []byte(*resp.Parameter.Value), Metadata: md}, nil
+}
+
+// Check confirms that the parameter and the requested version or label exist.
+// It reads the parameter without decryption, so SecureString values are never
+// decrypted and KMS access is not checked.
+func (p *Provider) Check(ctx context.Context, req provider.Request) error {
+	l, err := parseRequest(req)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	_, err = p.getParameter(ctx, l, false)
+	return err
+}
+
+// lookup is a decoded request: the parameter to read and how to reach it.
+type lookup struct {
+	key            clientKey
+	timeout        time.Duration
+	name           string
+	withDecryption bool
+}
+
+func parseRequest(req provider.Request) (lookup, error) {
+	opts, err := provider.DecodeOptions[options](req.Options)
+	if err != nil {
+		return lookup{}, err
+	}
+
+	r, err := ref.Parse(req.Ref)
+	if err != nil {
+		return lookup{}, err
+	}
+	paramName := r.Path
+	if paramName == "" {
+		return lookup{}, fmt.Errorf("ref must include the parameter name")
+	}
+	// SSM selects versions and labels with a name:<selector> suffix.
+	switch {
+	case r.Version != "":
+		paramName += ":" + r.Version
+	case r.Stage != "":
+		paramName += ":" + r.Stage
+	}
+
+	var withDecryption = true
+	if opts.WithDecryption != nil {
+		withDecryption = *opts.WithDecryption
+	}
+
+	return lookup{
+		key:            clientKey{region: opts.Region, profile: opts.Profile},
+		timeout:        resolveTimeout(opts.Timeout, defaultAWSSSMTimeout),
+		name:           paramName,
+		withDecryption: withDecryption,
+	}, nil
+}
+
+func (p *Provider) getParameter(ctx context.Context, l lookup, withDecryption bool) (*ssm.GetParameterOutput, error) {
+	client, err := p.clients.Get(l.key, func() (*ssm.Client, error) {
+		cfg, err := loadConfig(ctx, l.key)
+		if err != nil {
+			return nil, err
+		}
+		return ssm.NewFromConfig(cfg), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           &l.name,
+		WithDecryption: &withDecryption,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get parameter %q: %w", l.name, err)
+	}
+	if resp.Parameter == nil {
+		return nil, fmt.Errorf("parameter missing in response")
+	}
+	return resp, nil
+}
+
+func loadConfig(ctx context.Context, key clientKey) (aws.Config, error) {
+	var cfgOpts []func(*config.LoadOptions) error
+	if key.region != "" {
+		cfgOpts = append(cfgOpts, config.WithRegion(key.region))
+	}
+	if key.profile != "" {
+		cfgOpts = append(cfgOpts, config.WithSharedConfigProfile(key.profile))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, cfgOpts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("load aws config: %w", err)
+	}
+	return cfg, nil
+}
+
+func resolveTimeout(given time.Duration, fallback time.Duration) time.Duration {
+	if given <= 0 {
+		return fallback
+	}
+	return given
+}
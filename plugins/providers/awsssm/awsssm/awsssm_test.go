@@ -1,4 +1,4 @@
-package main
+package awsssm
 
 import (
 	"context"
@@ -145,7 +145,7 @@ func TestResolveTimeout(t *testing.T) {
 func TestHandleRequiresParameterName(t *testing.T) {
 	t.Parallel()
 
-	_, err := handle(provider.Request{Ref: ""})
+	_, err := New().Handle(context.Background(), provider.Request{Ref: ""})
 	if assert.Error(t, err) {
 		assert.EqualError(t, err, "ref must include the parameter name")
 	}
@@ -156,7 +156,7 @@ func TestHandleReturnsParameterValue(t *testing.T) {
 	paramName := uniqueParameterName("string")
 	createParameter(t, client, paramName, "plain-value", ssmtypes.ParameterTypeString)
 
-	resp, err := handle(provider.Request{Ref: paramName})
+	resp, err := New().Handle(context.Background(), provider.Request{Ref: paramName})
 	require.NoError(t, err)
 	assert.Equal(t, "plain-value", string(resp.Value))
 }
@@ -166,7 +166,7 @@ func TestHandleDecryptsSecureStringByDefault(t *testing.T) {
 	paramName := uniqueParameterName("secure-default")
 	createParameter(t, client, paramName, "top-secret", ssmtypes.ParameterTypeSecureString)
 
-	resp, err := handle(provider.Request{Ref: paramName})
+	resp, err := New().Handle(context.Background(), provider.Request{Ref: paramName})
 	require.NoError(t, err)
 	assert.Equal(t, "top-secret", string(resp.Value))
 }
@@ -179,10 +179,21 @@ func TestHandleHonorsWithDecryptionOption(t *testing.T) {
 	encryptedValue := getParameterValue(t, client, paramName, false)
 	require.NotEqual(t, "option-secret", encryptedValue, "expected encrypted value to differ from plaintext")
 
-	resp, err := handle(provider.Request{
+	resp, err := New().Handle(context.Background(), provider.Request{
 		Ref:     paramName,
 		Options: []byte("with_decryption: false\n"),
 	})
 	require.NoError(t, err)
 	assert.Equal(t, encryptedValue, string(resp.Value))
 }
+
+func TestCheckConfirmsParameterWithoutDecryption(t *testing.T) {
+	client := newLocalstackClient(t)
+	paramName := uniqueParameterName("check")
+	createParameter(t, client, paramName, "check-secret", ssmtypes.ParameterTypeSecureString)
+
+	p := New()
+	require.NoError(t, p.Check(context.Background(), provider.Request{Ref: paramName}))
+	assert.Error(t, p.Check(context.Background(), provider.Request{Ref: paramName + "#version:9"}))
+	assert.Error(t, p.Check(context.Background(), provider.Request{Ref: uniqueParameterName("absent")}))
+}
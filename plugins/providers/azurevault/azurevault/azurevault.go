@@ -0,0 +1,273 @@
+// Package azurevault reads secrets from Azure Key Vault.
+package azurevault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+
+	"github.com/fr0stylo/sfx/provider"
+	"github.com/fr0stylo/sfx/provider/ref"
+)
+
+const defaultAzureVaultTimeout = 30 * time.Second
+
+type options struct {
+	VaultURL  string        `yaml:"vault_url" desc:"Key Vault URL (https://<vault>.vault.azure.net)"`
+	VaultName string        `yaml:"vault_name" desc:"Key Vault name used to derive the URL"`
+	Secret    string        `yaml:"secret" desc:"Secret name used when the ref is empty"`
+	Version   string        `yaml:"version" desc:"Secret version (default latest)"`
+	Timeout   time.Duration `yaml:"timeout" default:"30s" desc:"Request timeout"`
+}
+
+// Options returns the handshake options describing the provider.
+func Options() []provider.Option {
+	return []provider.Option{
+		provider.WithRefFormat("https://<vault>.vault.azure.net/secrets/<secret>[/<version>] or <vault>/<secret>[#<version>]"),
+		provider.WithOptions(options{}),
+	}
+}
+
+// Provider reads secrets from Key Vault with one credential, created by Init,
+// and one client per vault URL reused across requests.
+type Provider struct {
+	cred    *azidentity.DefaultAzureCredential
+	clients provider.Cache[string, *azsecrets.Client]
+}
+
+// New returns a Provider with an empty client cache.
+func New() *Provider {
+	return &Provider{}
+}
+
+// Init obtains the default Azure credential shared by all vault clients.
+func (p *Provider) Init(context.Context) error {
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return fmt.Errorf("obtain azure credential: %w", err)
+	}
+	p.cred = cred
+	return nil
+}
+
+// Handle serves a single provider request.
+func (p *Provider) Handle(ctx context.Context, req provider.Request) (provider.Response, error) {
+	opts, err := provider.DecodeOptions[options](req.Options)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	vaultURL, secretName, version, err := resolveTarget(req.Ref, opts)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout(opts.Timeout, defaultAzureVaultTimeout))
+	defer cancel()
+
+	client, err := p.client(vaultURL)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	resp, err := client.GetSecret(ctx, secretName, version, nil)
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("get secret %q: %w", secretName, err)
+	}
+
+	if resp.Value == nil {
+		return provider.Response{}, errors.New("secret value empty")
+	}
+
+	return provider.Response{Value: []byte(*resp.Value), Metadata: secretMetadata(resp.Secret)}, nil
+}
+
+// Check confirms that the secret has an enabled version, or that the requested
+// version exists and is enabled, by listing version properties, which carry no
+// values. It needs the list permission on secrets rather than get.
+func (p *Provider) Check(ctx context.Context, req provider.Request) error {
+	opts, err := provider.DecodeOptions[options](req.Options)
+	if err != nil {
+		return err
+	}
+
+	vaultURL, secretName, version, err := resolveTarget(req.Ref, opts)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout(opts.Timeout, defaultAzureVaultTimeout))
+	defer cancel()
+
+	client, err := p.client(vaultURL)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	pager := client.NewListSecretPropertiesVersionsPager(secretName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("list versions of secret %q: %w", secretName, err)
+		}
+		for _, props := range page.Value {
+			if props.ID == nil || (version != "" && props.ID.Version() != version) {
+				continue
+			}
+			found = true
+			if a := props.Attributes; a == nil || a.Enabled == nil || *a.Enabled {
+				return nil
+			}
+		}
+	}
+
+	switch {
+	case version == "" && !found:
+		return fmt.Errorf("secret %q has no versions", secretName)
+	case version == "":
+		return fmt.Errorf("secret %q has no enabled version", secretName)
+	case !found:
+		return fmt.Errorf("secret %q has no version %q", secretName, version)
+	default:
+		return fmt.Errorf("secret %q version %q is disabled", secretName, version)
+	}
+}
+
+func (p *Provider) client(vaultURL string) (*azsecrets.Client, error) {
+	return p.clients.Get(vaultURL, func() (*azsecrets.Client, error) {
+		client, err := azsecrets.NewClient(vaultURL, p.cred, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create key vault client: %w", err)
+		}
+		return client, nil
+	})
+}
+
+func secretMetadata(s azsecrets.Secret) map[string]string {
+	md := map[string]string{}
+	if s.ID != nil {
+		md[provider.MetaVersion] = s.ID.Version()
+	}
+	if s.ContentType != nil {
+		md[provider.MetaContentType] = *s.ContentType
+	}
+	if a := s.Attributes; a != nil {
+		for key, t := range map[string]*time.Time{
+			provider.MetaCreated: a.Created,
+			provider.MetaUpdated: a.Updated,
+			provider.MetaExpires: a.Expires,
+		} {
+			if t != nil {
+				md[key] = provider.FormatTime(*t)
+			}
+		}
+	}
+	return md
+}
+
+func resolveTarget(s string, opts options) (string, string, string, error) {
+	r, err := ref.Parse(s)
+	if err != nil {
+		return "", "", "", err
+	}
+	path := r.Path
+	// A bare fragment is the version, as in "vault/secret#<version>".
+	version := firstNonEmpty(r.Version, r.Field)
+
+	if r.Scheme == "http" || r.Scheme == "https" {
+		u, err := url.Parse(r.Scheme + "://" + path)
+		if err != nil {
+			return "", "", "", fmt.Errorf("parse vault url: %w", err)
+		}
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(segments) < 2 || !strings.EqualFold(segments[0], "secrets") {
+			return "", "", "", fmt.Errorf("invalid azure vault path %q", u.String())
+		}
+		secretName := segments[1]
+		if version == "" && len(segments) > 2 {
+			version = segments[2]
+		}
+		return ensureVaultURL(u.Scheme + "://" + u.Host), secretName, firstNonEmpty(version, opts.Version, ""), nil
+	}
+
+	vaultURL := strings.TrimSpace(opts.VaultURL)
+	secretName := strings.TrimSpace(opts.Secret)
+
+	if path != "" {
+		trimmed := strings.Trim(path, "/")
+		if trimmed != "" {
+			parts := strings.Split(trimmed, "/")
+			switch len(parts) {
+			case 1:
+				secretName = parts[0]
+			default:
+				if secretName == "" {
+					secretName = parts[1]
+				}
+				if vaultURL == "" {
+					vaultURL = inferVaultURL(parts[0], opts.VaultName)
+				}
+				if len(parts) > 2 && version == "" {
+					version = parts[2]
+				}
+			}
+		}
+	}
+
+	if vaultURL == "" && opts.VaultName != "" {
+		vaultURL = inferVaultURL(opts.VaultName, "")
+	}
+	if secretName == "" {
+		return "", "", "", errors.New("secret name missing (ref or options.secret)")
+	}
+	version = firstNonEmpty(version, opts.Version)
+
+	if vaultURL == "" {
+		return "", "", "", errors.New("vault url not provided (options.vault_url, options.vault_name, or ref 'vault/secret')")
+	}
+
+	return ensureVaultURL(vaultURL), secretName, version, nil
+}
+
+func ensureVaultURL(raw string) string {
+	u := strings.TrimRight(raw, "/")
+	return u
+}
+
+func inferVaultURL(vaultRef string, fallback string) string {
+	name := strings.TrimSpace(vaultRef)
+	if name == "" {
+		name = strings.TrimSpace(fallback)
+	}
+	if name == "" {
+		return ""
+	}
+	if strings.HasPrefix(name, "http") {
+		return ensureVaultURL(name)
+	}
+	name = strings.TrimSuffix(name, ".vault.azure.net")
+	return fmt.Sprintf("https://%s.vault.azure.net", name)
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func resolveTimeout(given time.Duration, fallback time.Duration) time.Duration {
+	if given <= 0 {
+		return fallback
+	}
+	return given
+}
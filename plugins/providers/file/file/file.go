@@ -0,0 +1,112 @@
+// Package file reads secrets from local env files.
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fr0stylo/sfx/provider"
+	"github.com/fr0stylo/sfx/provider/ref"
+)
+
+type options struct {
+	Path string `yaml:"path" required:"true" desc:"Path to the env file to read"`
+}
+
+// Options returns the handshake options describing the provider.
+func Options() []provider.Option {
+	return []provider.Option{
+		provider.WithRefFormat("env://<KEY>"),
+		provider.WithOptions(options{}),
+	}
+}
+
+// Handler returns the provider's handler, which also checks refs.
+func Handler() provider.Handler {
+	return provider.HandlerFuncs{HandleFunc: Handle, CheckFunc: Check}
+}
+
+// Handle serves a single provider request.
+func Handle(_ context.Context, req provider.Request) (provider.Response, error) {
+	f, r, err := open(req)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	var md map[string]string
+	if info, err := f.Stat(); err == nil {
+		md = map[string]string{provider.MetaUpdated: provider.FormatTime(info.ModTime())}
+	}
+
+	buf, err := lookup(f, r.Path)
+	return provider.Response{Value: buf, Metadata: md}, err
+}
+
+// Check confirms that the env file is readable and defines the key.
+func Check(_ context.Context, req provider.Request) error {
+	f, r, err := open(req)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+
+	_, err = lookup(f, r.Path)
+	return err
+}
+
+// lookup returns the value of key in the env file f.
+func lookup(f *os.File, key string) ([]byte, error) {
+	value, found, err := parseEnvFile(f, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("key %q not found in %s", key, f.Name())
+	}
+	return value, nil
+}
+
+// open decodes the request and opens the env file it names.
+func open(req provider.Request) (*os.File, ref.Ref, error) {
+	opts, err := provider.DecodeOptions[options](req.Options)
+	if err != nil {
+		return nil, ref.Ref{}, err
+	}
+
+	r, err := ref.Parse(req.Ref)
+	if err != nil {
+		return nil, ref.Ref{}, err
+	}
+	if r.Scheme != "env" {
+		return nil, ref.Ref{}, fmt.Errorf("unsupported scheme %q", r.Scheme)
+	}
+
+	f, err := os.Open(opts.Path)
+	if err != nil {
+		return nil, ref.Ref{}, fmt.Errorf("open file: %w", err)
+	}
+	return f, r, nil
+}
+
+// parseEnvFile returns the value assigned to key by a KEY=value line, which may
+// start with "export ". Quotes around the value are removed.
+func parseEnvFile(r io.Reader, key []byte) ([]byte, bool, error) {
+	scan := bufio.NewScanner(r)
+	scan.Split(bufio.ScanLines)
+	for scan.Scan() {
+		line := bytes.TrimLeft(scan.Bytes(), " \t")
+		line = bytes.TrimPrefix(line, []byte("export "))
+		name, value, ok := bytes.Cut(line, []byte("="))
+		if !ok || !bytes.Equal(bytes.TrimSpace(name), key) {
+			continue
+		}
+		return bytes.Trim(value, " \t\r\n\"'"), true, nil
+	}
+
+	return nil, false, scan.Err()
+}
@@ -0,0 +1,143 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fr0stylo/sfx/provider"
+	"github.com/fr0stylo/sfx/provider/providertest"
+)
+
+func writeTempFile(t *testing.T, contents string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "env")
+	err := os.WriteFile(path, []byte(contents), 0o600)
+	require.NoError(t, err, "write temp file")
+	return path
+}
+
+func optionsYAML(path string) []byte {
+	return []byte(fmt.Sprintf("path: %s\n", path))
+}
+
+func TestHandleReturnsValueWhenLineMatches(t *testing.T) {
+	path := writeTempFile(t, "FOO=bar\nBAR=baz\n")
+
+	resp, err := Handle(context.Background(), provider.Request{
+		Ref:     "env://FOO",
+		Options: optionsYAML(path),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(resp.Value))
+}
+
+func TestHandleMatchesWholeKey(t *testing.T) {
+	path := writeTempFile(t, "DB_PASS=secret\nexport DB=main\n")
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{ref: "env://DB", want: "main"},
+		{ref: "env://DB_PASS", want: "secret"},
+		{ref: "env://DB_", wantErr: `key "DB_" not found`},
+		{ref: "env://BAZ", wantErr: `key "BAZ" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			req := provider.Request{Ref: tt.ref, Options: optionsYAML(path)}
+
+			resp, err := Handle(context.Background(), req)
+			checkErr := Check(context.Background(), req)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				require.Error(t, checkErr)
+				assert.Contains(t, checkErr.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, checkErr)
+			assert.Equal(t, tt.want, string(resp.Value))
+		})
+	}
+}
+
+func TestHandlePropagatesYAMLError(t *testing.T) {
+	_, err := Handle(context.Background(), provider.Request{
+		Ref:     "env://FOO",
+		Options: []byte("path: ["),
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "yaml")
+	}
+}
+
+func TestHandleRequiresPath(t *testing.T) {
+	_, err := Handle(context.Background(), provider.Request{Ref: "env://FOO"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "path: required option is not set")
+	}
+}
+
+func TestHandlePropagatesFileOpenError(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	_, err := Handle(context.Background(), provider.Request{
+		Ref:     "env://FOO",
+		Options: optionsYAML(missing),
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "open file")
+	}
+}
+
+func TestParseEnvFileReturnsValueWithoutSeparator(t *testing.T) {
+	buf, found, err := parseEnvFile(bytes.NewBufferString("FOO=bar\n"), []byte("FOO"))
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "bar", string(buf))
+}
+
+func TestServesOverPluginProtocol(t *testing.T) {
+	path := writeTempFile(t, "FOO=bar\n")
+	p := providertest.Start(t, Handler(), Options()...)
+
+	assert.Equal(t, "env://<KEY>", p.RefFormat())
+	p.ExpectValue("env://FOO", map[string]any{"path": path}, "bar")
+	p.ExpectError("env://FOO", map[string]any{"path": filepath.Join(t.TempDir(), "missing")}, "open file")
+	p.ExpectError("vault://FOO", map[string]any{"path": path}, "unsupported scheme")
+
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	_, md, err := p.FetchMetadata("env://FOO", map[string]any{"path": path})
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-01T12:00:00Z", md[provider.MetaUpdated])
+}
+
+func TestCheckConfirmsKeyWithoutValue(t *testing.T) {
+	path := writeTempFile(t, "FOO=bar\n")
+	p := providertest.Start(t, Handler(), Options()...)
+
+	require.True(t, p.SupportsCheck())
+	require.NoError(t, p.Check("env://FOO", map[string]any{"path": path}))
+
+	err := p.Check("env://BAR", map[string]any{"path": path})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `key "BAR" not found`)
+
+	err = p.Check("env://FOO", map[string]any{"path": filepath.Join(t.TempDir(), "missing")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "open file")
+}
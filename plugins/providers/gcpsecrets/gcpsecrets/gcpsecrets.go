@@ -0,0 +1,177 @@
+// Package gcpsecrets reads secrets from Google Cloud Secret Manager.
+package gcpsecrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+
+	"github.com/fr0stylo/sfx/provider"
+	"github.com/fr0stylo/sfx/provider/ref"
+)
+
+const defaultGCPSecretTimeout = 30 * time.Second
+
+type options struct {
+	Project string        `yaml:"project" desc:"GCP project ID"`
+	Secret  string        `yaml:"secret" desc:"Secret name used when the ref is empty"`
+	Version string        `yaml:"version" default:"latest" desc:"Secret version"`
+	Timeout time.Duration `yaml:"timeout" default:"30s" desc:"Request timeout"`
+}
+
+// Options returns the handshake options describing the provider.
+func Options() []provider.Option {
+	return []provider.Option{
+		provider.WithRefFormat("projects/<project>/secrets/<secret>[/versions/<version>] or [<project>/]<secret>#<version>"),
+		provider.WithOptions(options{}),
+	}
+}
+
+// Provider reads secrets from Secret Manager through a single client shared by
+// all requests; secret names carry the project, so no option affects the client.
+type Provider struct {
+	client *secretmanager.Client
+}
+
+// New returns a Provider; its client is created by Init.
+func New() *Provider {
+	return &Provider{}
+}
+
+// Init creates the Secret Manager client.
+func (p *Provider) Init(ctx context.Context) error {
+	client, err := secretmanager.NewClient(context.WithoutCancel(ctx))
+	if err != nil {
+		return fmt.Errorf("create secret manager client: %w", err)
+	}
+	p.client = client
+	return nil
+}
+
+// Shutdown closes the Secret Manager client.
+func (p *Provider) Shutdown(context.Context) error {
+	return p.client.Close()
+}
+
+// Handle serves a single provider request.
+func (p *Provider) Handle(ctx context.Context, req provider.Request) (provider.Response, error) {
+	opts, err := provider.DecodeOptions[options](req.Options)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	name, err := resolveResource(req.Ref, opts)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout(opts.Timeout, defaultGCPSecretTimeout))
+	defer cancel()
+
+	resp, err := p.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: name,
+	})
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("access %q: %w", name, err)
+	}
+
+	// The response names the version that was read, also when the ref asked
+	// for "latest".
+	md := map[string]string{}
+	if v := path.Base(resp.GetName()); v != "" && v != "." {
+		md[provider.MetaVersion] = v
+	}
+	return provider.Response{Value: resp.GetPayload().GetData(), Metadata: md}, nil
+}
+
+// Check confirms that the secret version exists and is enabled by reading its
+// metadata with GetSecretVersion, which does not return the payload.
+func (p *Provider) Check(ctx context.Context, req provider.Request) error {
+	opts, err := provider.DecodeOptions[options](req.Options)
+	if err != nil {
+		return err
+	}
+
+	name, err := resolveResource(req.Ref, opts)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout(opts.Timeout, defaultGCPSecretTimeout))
+	defer cancel()
+
+	version, err := p.client.GetSecretVersion(ctx, &secretmanagerpb.GetSecretVersionRequest{
+		Name: name,
+	})
+	if err != nil {
+		return fmt.Errorf("get %q: %w", name, err)
+	}
+	if state := version.GetState(); state != secretmanagerpb.SecretVersion_ENABLED {
+		return fmt.Errorf("version %q is %s", version.GetName(), strings.ToLower(state.String()))
+	}
+	return nil
+}
+
+func resolveResource(s string, opts options) (string, error) {
+	r, err := ref.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	// A bare fragment is the version, as in "app-db#3".
+	version := firstNonEmpty(r.Version, r.Field, opts.Version, "latest")
+
+	if strings.HasPrefix(r.Path, "projects/") {
+		if strings.Contains(r.Path, "/versions/") {
+			return r.Path, nil
+		}
+		return fmt.Sprintf("%s/versions/%s", strings.TrimSuffix(r.Path, "/"), version), nil
+	}
+
+	secretPart := r.Path
+	if secretPart == "" {
+		secretPart = opts.Secret
+	}
+
+	project := opts.Project
+	if secretPart != "" {
+		if strings.Contains(secretPart, "/") {
+			parts := strings.SplitN(secretPart, "/", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return "", fmt.Errorf("invalid secret ref %q", secretPart)
+			}
+			project = parts[0]
+			secretPart = parts[1]
+		}
+	}
+
+	if secretPart == "" {
+		return "", errors.New("secret name missing (ref or options.secret)")
+	}
+	if project == "" {
+		return "", errors.New("project missing (options.project or ref 'project/secret')")
+	}
+
+	return fmt.Sprintf("projects/%s/secrets/%s/versions/%s", project, secretPart, version), nil
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func resolveTimeout(given time.Duration, fallback time.Duration) time.Duration {
+	if given <= 0 {
+		return fallback
+	}
+	return given
+}
@@ -0,0 +1,279 @@
+// Package sops reads values from SOPS-encrypted files.
+package sops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/cmd/sops/common"
+	"github.com/getsops/sops/v3/cmd/sops/formats"
+	"github.com/getsops/sops/v3/config"
+	"github.com/getsops/sops/v3/decrypt"
+	"gopkg.in/yaml.v3"
+
+	"github.com/fr0stylo/sfx/provider"
+	"github.com/fr0stylo/sfx/provider/ref"
+)
+
+type options struct {
+	Path    string `yaml:"path" desc:"Encrypted file used when the ref has no file part"`
+	Format  string `yaml:"format" desc:"Input format (yaml, json, dotenv, ini, binary)"`
+	KeyPath string `yaml:"key_path" desc:"Dotted key path used when the ref has no #path"`
+}
+
+// Options returns the handshake options describing the provider.
+func Options() []provider.Option {
+	return []provider.Option{
+		provider.WithRefFormat("<file>#<key.path>"),
+		provider.WithOptions(options{}),
+	}
+}
+
+// Handler returns the provider's handler, which also checks refs.
+func Handler() provider.Handler {
+	return provider.HandlerFuncs{HandleFunc: Handle, CheckFunc: Check}
+}
+
+// Handle serves a single provider request.
+func Handle(_ context.Context, req provider.Request) (provider.Response, error) {
+	opts, path, key, err := parseRequest(req)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	cleartext, err := decrypt.File(path, opts.Format)
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("decrypt %q: %w", path, err)
+	}
+
+	if key == "" {
+		return provider.Response{Value: cleartext}, nil
+	}
+
+	var root any
+	if err := yaml.Unmarshal(cleartext, &root); err != nil {
+		return provider.Response{}, fmt.Errorf("decode decrypted payload: %w", err)
+	}
+
+	value, err := navigate(root, parsePath(key))
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	buf, err := encodeValue(value)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	return provider.Response{Value: buf}, nil
+}
+
+// Check confirms that the file is SOPS-encrypted, that its data key can be
+// recovered with the available master keys and that the key path exists.
+// Keys are stored in cleartext, so no value is decrypted.
+func Check(_ context.Context, req provider.Request) error {
+	opts, path, key, err := parseRequest(req)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %q: %w", path, err)
+	}
+	store := common.StoreForFormat(formats.FormatForPathOrString(path, opts.Format), config.NewStoresConfig())
+	tree, err := store.LoadEncryptedFile(data)
+	if err != nil {
+		return fmt.Errorf("load %q: %w", path, err)
+	}
+	if _, err := tree.Metadata.GetDataKey(); err != nil {
+		return fmt.Errorf("recover data key of %q: %w", path, err)
+	}
+
+	if key == "" {
+		return nil
+	}
+	var node any = tree.Branches[0]
+	for _, part := range parsePath(key) {
+		if node, err = child(node, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// child returns the element of an encrypted tree node named by part.
+func child(node any, part string) (any, error) {
+	switch val := node.(type) {
+	case sops.TreeBranch:
+		for _, item := range val {
+			if item.Key == part {
+				return item.Value, nil
+			}
+		}
+		return nil, fmt.Errorf("key %q not found", part)
+	case []any:
+		idx, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("expected numeric index, got %q", part)
+		}
+		if idx < 0 || idx >= len(val) {
+			return nil, fmt.Errorf("index %d out of range", idx)
+		}
+		return val[idx], nil
+	default:
+		return nil, fmt.Errorf("cannot descend into %T for segment %q", node, part)
+	}
+}
+
+func parseRequest(req provider.Request) (opts options, path, key string, err error) {
+	opts, err = provider.DecodeOptions[options](req.Options)
+	if err != nil {
+		return options{}, "", "", err
+	}
+
+	r, err := ref.Parse(req.Ref)
+	if err != nil {
+		return options{}, "", "", err
+	}
+
+	path, key = r.Path, r.Field
+	if path == "" {
+		path = opts.Path
+	}
+	if path == "" {
+		return options{}, "", "", errors.New("ref must include a file path or options.path must be set")
+	}
+	if key == "" {
+		key = opts.KeyPath
+	}
+	return opts, path, key, nil
+}
+
+func parsePath(path string) []string {
+	var (
+		parts   []string
+		current strings.Builder
+		escape  bool
+	)
+
+	for _, r := range path {
+		switch {
+		case escape:
+			current.WriteRune(r)
+			escape = false
+		case r == '\\':
+			escape = true
+		case r == '.' || r == '/':
+			if current.Len() > 0 {
+				parts = append(parts, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
+
+func navigate(node any, path []string) (any, error) {
+	if len(path) == 0 {
+		return node, nil
+	}
+
+	head, tail := path[0], path[1:]
+
+	switch val := node.(type) {
+	case map[string]any:
+		child, ok := val[head]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found", head)
+		}
+		return navigate(child, tail)
+	case map[any]any:
+		if child, ok := val[head]; ok {
+			return navigate(child, tail)
+		}
+		for k, v := range val {
+			if ks, ok := k.(string); ok && ks == head {
+				return navigate(v, tail)
+			}
+		}
+		return nil, fmt.Errorf("key %q not found", head)
+	case []any:
+		idx, err := strconv.Atoi(head)
+		if err != nil {
+			return nil, fmt.Errorf("expected numeric index, got %q", head)
+		}
+		if idx < 0 || idx >= len(val) {
+			return nil, fmt.Errorf("index %d out of range", idx)
+		}
+		return navigate(val[idx], tail)
+	default:
+		return nil, fmt.Errorf("cannot descend into %T for segment %q", node, head)
+	}
+}
+
+func encodeValue(v any) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return t, nil
+	case string:
+		return []byte(t), nil
+	case fmt.Stringer:
+		return []byte(t.String()), nil
+	case json.RawMessage:
+		return t, nil
+	case []any, map[string]any, map[any]any:
+		normalized := normalize(t)
+		buf, err := json.Marshal(normalized)
+		if err != nil {
+			return nil, fmt.Errorf("marshal value: %w", err)
+		}
+		return buf, nil
+	default:
+		buf, err := json.Marshal(t)
+		if err != nil {
+			return []byte(fmt.Sprint(t)), nil
+		}
+		return buf, nil
+	}
+}
+
+func normalize(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, sub := range val {
+			out[k] = normalize(sub)
+		}
+		return out
+	case map[any]any:
+		out := make(map[string]any, len(val))
+		for k, sub := range val {
+			key := fmt.Sprint(k)
+			out[key] = normalize(sub)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, sub := range val {
+			out[i] = normalize(sub)
+		}
+		return out
+	default:
+		return val
+	}
+}
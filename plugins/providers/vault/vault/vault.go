@@ -0,0 +1,282 @@
+// Package vault reads secrets from HashiCorp Vault.
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/fr0stylo/sfx/provider"
+	"github.com/fr0stylo/sfx/provider/ref"
+)
+
+type options struct {
+	Address   string        `yaml:"address" desc:"Vault server address (defaults to VAULT_ADDR)"`
+	Token     string        `yaml:"token" desc:"Vault token (defaults to VAULT_TOKEN)"`
+	Namespace string        `yaml:"namespace" desc:"Vault Enterprise namespace"`
+	Field     string        `yaml:"field" desc:"Field to extract when the ref has no #field"`
+	Timeout   time.Duration `yaml:"timeout" desc:"Client timeout"`
+}
+
+// Options returns the handshake options describing the provider.
+func Options() []provider.Option {
+	return []provider.Option{
+		provider.WithRefFormat("<path>[?<param>=<value>][#<field>|#version:<n>]"),
+		provider.WithOptions(options{}),
+	}
+}
+
+// Provider reads secrets from Vault, reusing one client per address, token,
+// namespace and timeout across requests.
+type Provider struct {
+	clients provider.Cache[clientKey, *vault.Client]
+}
+
+type clientKey struct {
+	address   string
+	token     string
+	namespace string
+	timeout   time.Duration
+}
+
+// New returns a Provider with an empty client cache.
+func New() *Provider {
+	return &Provider{}
+}
+
+// Handle serves a single provider request.
+func (p *Provider) Handle(ctx context.Context, req provider.Request) (provider.Response, error) {
+	client, r, field, err := p.prepare(req)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	// Query parameters and #version:<n> are passed to the read, which selects
+	// a KV v2 secret version.
+	params := r.Query
+	if r.Version != "" {
+		if params == nil {
+			params = url.Values{}
+		}
+		params.Set("version", r.Version)
+	}
+
+	secret, err := client.Logical().ReadWithDataWithContext(ctx, r.Path, params)
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("read secret %q: %w", r.Path, err)
+	}
+	if secret == nil {
+		return provider.Response{}, fmt.Errorf("secret %q not found", r.Path)
+	}
+
+	value, err := extractValue(secret.Data, field)
+	if err != nil {
+		return provider.Response{}, fmt.Errorf("extract value: %w", err)
+	}
+
+	return provider.Response{Value: value, Metadata: secretMetadata(secret.Data)}, nil
+}
+
+// Check confirms access to the secret without reading its value. KV v2 paths
+// (<mount>/data/<path>) are checked through the secret's metadata: the requested
+// version, or the current one, must exist and be neither deleted nor destroyed.
+// Other paths are checked for the read capability of the token. Fields are not
+// checked, as they are only known from the value.
+func (p *Provider) Check(ctx context.Context, req provider.Request) error {
+	client, r, _, err := p.prepare(req)
+	if err != nil {
+		return err
+	}
+
+	metaPath, ok := kv2MetadataPath(r.Path)
+	if !ok {
+		caps, err := client.Sys().CapabilitiesSelfWithContext(ctx, r.Path)
+		if err != nil {
+			return fmt.Errorf("check capabilities on %q: %w", r.Path, err)
+		}
+		if !slices.Contains(caps, "read") && !slices.Contains(caps, "root") {
+			return fmt.Errorf("token cannot read %q (capabilities: %s)", r.Path, strings.Join(caps, ", "))
+		}
+		return nil
+	}
+
+	meta, err := client.Logical().ReadWithContext(ctx, metaPath)
+	if err != nil {
+		return fmt.Errorf("read metadata %q: %w", metaPath, err)
+	}
+	if meta == nil {
+		return fmt.Errorf("secret %q not found", r.Path)
+	}
+	return checkVersion(r.Path, r.Version, meta.Data)
+}
+
+// prepare decodes the request options and ref and returns the client to read
+// with and the field to extract.
+func (p *Provider) prepare(req provider.Request) (*vault.Client, ref.Ref, string, error) {
+	opts, err := provider.DecodeOptions[options](req.Options)
+	if err != nil {
+		return nil, ref.Ref{}, "", err
+	}
+
+	key := clientKey{
+		address:   firstNonEmpty(opts.Address, os.Getenv("VAULT_ADDR")),
+		token:     firstNonEmpty(opts.Token, os.Getenv("VAULT_TOKEN")),
+		namespace: opts.Namespace,
+		timeout:   opts.Timeout,
+	}
+
+	if key.address == "" {
+		return nil, ref.Ref{}, "", errors.New("vault address not provided (set options.address or VAULT_ADDR)")
+	}
+	if key.token == "" {
+		return nil, ref.Ref{}, "", errors.New("vault token not provided (set options.token or VAULT_TOKEN)")
+	}
+
+	client, err := p.clients.Get(key, func() (*vault.Client, error) {
+		return newClient(key)
+	})
+	if err != nil {
+		return nil, ref.Ref{}, "", err
+	}
+
+	r, err := ref.Parse(req.Ref)
+	if err != nil {
+		return nil, ref.Ref{}, "", err
+	}
+	if r.Path == "" {
+		return nil, ref.Ref{}, "", errors.New("ref must include a vault path")
+	}
+	return client, r, firstNonEmpty(r.Field, opts.Field), nil
+}
+
+// kv2MetadataPath maps a KV v2 data path, <mount>/data/<path>, to the path of
+// its metadata.
+func kv2MetadataPath(path string) (string, bool) {
+	mount, rest, ok := strings.Cut(strings.Trim(path, "/"), "/data/")
+	if !ok || mount == "" || rest == "" {
+		return "", false
+	}
+	return mount + "/metadata/" + rest, true
+}
+
+// checkVersion reports whether the KV v2 metadata in data lists version, or the
+// current version when it is empty, as readable.
+func checkVersion(path, version string, data map[string]any) error {
+	if version == "" {
+		version = string(formatValue(data["current_version"]))
+	}
+	versions, _ := data["versions"].(map[string]any)
+	v, ok := versions[version].(map[string]any)
+	if !ok {
+		return fmt.Errorf("secret %q has no version %s", path, version)
+	}
+	if destroyed, _ := v["destroyed"].(bool); destroyed {
+		return fmt.Errorf("secret %q version %s is destroyed", path, version)
+	}
+	// A deletion time in the future is scheduled by delete_version_after.
+	if t, _ := v["deletion_time"].(string); t != "" {
+		if deleted, err := time.Parse(time.RFC3339Nano, t); err != nil || !deleted.After(time.Now()) {
+			return fmt.Errorf("secret %q version %s was deleted at %s", path, version, normalizeTime(t))
+		}
+	}
+	return nil
+}
+
+// secretMetadata reads the version metadata KV v2 returns next to the data.
+// KV v1 has none.
+func secretMetadata(data map[string]any) map[string]string {
+	meta, ok := data["metadata"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	md := map[string]string{}
+	if v, ok := meta["version"]; ok && v != nil {
+		md[provider.MetaVersion] = string(formatValue(v))
+	}
+	if t, ok := meta["created_time"].(string); ok {
+		md[provider.MetaCreated] = normalizeTime(t)
+	}
+	if t, ok := meta["deletion_time"].(string); ok && t != "" {
+		md[provider.MetaExpires] = normalizeTime(t)
+	}
+	return md
+}
+
+// normalizeTime converts Vault's nanosecond RFC 3339 times to the metadata
+// format, keeping values it cannot parse as they are.
+func normalizeTime(s string) string {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return s
+	}
+	return provider.FormatTime(t)
+}
+
+func newClient(key clientKey) (*vault.Client, error) {
+	config := vault.DefaultConfig()
+	config.Address = key.address
+	if key.timeout > 0 {
+		config.Timeout = key.timeout
+	}
+
+	client, err := vault.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("create vault client: %w", err)
+	}
+	client.SetToken(key.token)
+	if key.namespace != "" {
+		client.SetNamespace(key.namespace)
+	}
+	return client, nil
+}
+
+func extractValue(data map[string]any, field string) ([]byte, error) {
+	if nested, ok := data["data"].(map[string]any); ok {
+		data = nested
+	}
+
+	if field == "" {
+		if len(data) != 1 {
+			return nil, errors.New("field must be specified (ref '#field' or options.field)")
+		}
+		for _, v := range data {
+			return formatValue(v), nil
+		}
+		return nil, errors.New("secret data empty")
+	}
+
+	val, ok := data[field]
+	if !ok {
+		return nil, fmt.Errorf("field %q not found", field)
+	}
+	return formatValue(val), nil
+}
+
+func formatValue(v any) []byte {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return t
+	case string:
+		return []byte(t)
+	default:
+		return []byte(fmt.Sprint(t))
+	}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fr0stylo/sfx/internal/builtin"
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// Register links h into the sfx binary as the builtin provider name, addressed
+// as builtin:<name> in configuration. Requests are dispatched in-process with the
+// same semantics as Run, and the handler's Shutdown hook runs when the host
+// shuts its plugins down. It is meant to be called from init and panics if the
+// name is already taken or the options cannot be described.
+func Register(name string, h Handler, opts ...Option) {
+	hs, err := newSettings(opts).handshake(h)
+	if err != nil {
+		panic(fmt.Sprintf("provider: register %q: %v", name, err))
+	}
+	hs.ProtocolVersion = rpc.ProtocolVersion
+	lc := newLifecycle(h)
+
+	builtin.Register(hs.GetKind(), name, builtin.Plugin{
+		Handshake: hs,
+		Call: func(ctx context.Context, req proto.Message, resp proto.Message) error {
+			in, ok := req.(*rpc.SecretRequest)
+			if !ok {
+				return fmt.Errorf("provider: unexpected request type %T", req)
+			}
+
+			out, err := dispatch(ctx, lc, in)
+			if err != nil {
+				out = &rpc.SecretResponse{Error: err.Error()}
+			}
+			proto.Reset(resp)
+			proto.Merge(resp, out)
+			return nil
+		},
+		Shutdown: lc.shutdown,
+	})
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"errors"
+	"sync"
+)
+
+// Cache holds values, typically API clients, keyed by the options they were
+// built from, so requests with the same options share one client. It is safe
+// for concurrent use; the zero value is ready to use.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]*cacheEntry[V]
+}
+
+type cacheEntry[V any] struct {
+	mu    sync.Mutex
+	value V
+	ok    bool
+}
+
+// Get returns the value cached for key, calling build to create it on first use.
+// Concurrent calls for the same key wait for a single build. Errors are not
+// cached, so a failed build is retried by the next Get.
+func (c *Cache[K, V]) Get(key K, build func() (V, error)) (V, error) {
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = map[K]*cacheEntry[V]{}
+	}
+	e, ok := c.entries[key]
+	if !ok {
+		e = &cacheEntry[V]{}
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.ok {
+		v, err := build()
+		if err != nil {
+			return v, err
+		}
+		e.value, e.ok = v, true
+	}
+	return e.value, nil
+}
+
+// Close empties the cache, passing every built value to release when it is
+// not nil, and returns the joined release errors.
+func (c *Cache[K, V]) Close(release func(V) error) error {
+	c.mu.Lock()
+	entries := c.entries
+	c.entries = nil
+	c.mu.Unlock()
+
+	var errs []error
+	for _, e := range entries {
+		e.mu.Lock()
+		if e.ok && release != nil {
+			errs = append(errs, release(e.value))
+		}
+		e.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
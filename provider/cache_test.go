@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCacheBuildsOncePerKey(t *testing.T) {
+	var c Cache[string, *int]
+	var builds atomic.Int32
+	build := func() (*int, error) {
+		n := int(builds.Add(1))
+		return &n, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*int, 8)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = c.Get("a", build)
+		}()
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		if r != results[0] {
+			t.Fatalf("concurrent Get returned different values")
+		}
+	}
+	if other, _ := c.Get("b", build); other == results[0] {
+		t.Fatalf("different keys share a value")
+	}
+	if got := builds.Load(); got != 2 {
+		t.Fatalf("build called %d times, want 2", got)
+	}
+}
+
+func TestCacheRetriesFailedBuilds(t *testing.T) {
+	var c Cache[string, int]
+	if _, err := c.Get("a", func() (int, error) { return 0, errors.New("boom") }); err == nil {
+		t.Fatalf("expected build error")
+	}
+	v, err := c.Get("a", func() (int, error) { return 7, nil })
+	if err != nil || v != 7 {
+		t.Fatalf("Get = (%d, %v), want (7, nil)", v, err)
+	}
+}
+
+func TestCacheCloseReleasesValues(t *testing.T) {
+	var c Cache[string, int]
+	_, _ = c.Get("a", func() (int, error) { return 1, nil })
+	_, _ = c.Get("b", func() (int, error) { return 2, nil })
+
+	released := 0
+	err := c.Close(func(v int) error {
+		released += v
+		if v == 2 {
+			return errors.New("close b")
+		}
+		return nil
+	})
+	if released != 3 || err == nil || err.Error() != "close b" {
+		t.Fatalf("Close released %d with error %v, want 3 and close b", released, err)
+	}
+
+	v, _ := c.Get("a", func() (int, error) { return 5, nil })
+	if v != 5 {
+		t.Fatalf("Get after Close = %d, want a fresh build", v)
+	}
+}
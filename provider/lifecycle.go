@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Initializer is implemented by handlers that prepare shared state, such as API
+// clients, before serving. Init is called once, before the first request; if it
+// fails the request reports the error and Init is tried again on the next one.
+type Initializer interface {
+	Init(ctx context.Context) error
+}
+
+// Shutdowner is implemented by handlers that release resources when the plugin
+// stops serving: on EOF from the host, when Serve's listener closes, or when the
+// host shuts down builtins. It is only called after a successful Init, or for
+// handlers without Init once they have served a request.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// lifecycle runs a handler's optional Init and Shutdown hooks around the
+// requests it serves.
+type lifecycle struct {
+	h Handler
+
+	mu      sync.Mutex
+	started bool
+}
+
+func newLifecycle(h Handler) *lifecycle {
+	return &lifecycle{h: h}
+}
+
+func (l *lifecycle) init(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.started {
+		return nil
+	}
+	if i, ok := l.h.(Initializer); ok {
+		if err := i.Init(ctx); err != nil {
+			return fmt.Errorf("init: %w", err)
+		}
+	}
+	l.started = true
+	return nil
+}
+
+func (l *lifecycle) shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.started {
+		return nil
+	}
+	l.started = false
+	if s, ok := l.h.(Shutdowner); ok {
+		if err := s.Shutdown(ctx); err != nil {
+			return fmt.Errorf("shutdown: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+var logger = sync.OnceValue(func() *slog.Logger {
+	return rpc.NewLogger(os.Stderr)
+})
+
+// Logger returns a structured logger whose records are forwarded to the host
+// and filtered by the host's --log-level. Builtin plugins log straight to the
+// host's default logger.
+func Logger() *slog.Logger {
+	if !rpc.HostManaged() {
+		return slog.Default()
+	}
+	return logger()
+}
@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"time"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// Metadata keys for Response.Metadata. Exporters know them by these names, so
+// providers should prefer them over backend-specific spellings.
+const (
+	// MetaVersion identifies the version of the secret that was read.
+	MetaVersion = rpc.MetaVersion
+	// MetaCreated is when that version was created; see FormatTime.
+	MetaCreated = rpc.MetaCreated
+	// MetaUpdated is when the secret was last changed; see FormatTime.
+	MetaUpdated = rpc.MetaUpdated
+	// MetaExpires is when the secret stops being valid; see FormatTime.
+	MetaExpires = rpc.MetaExpires
+	// MetaContentType is the media type recorded for the value.
+	MetaContentType = rpc.MetaContentType
+	// MetaProvider and MetaRef are set by the host; providers leave them out.
+	MetaProvider = rpc.MetaProvider
+	MetaRef      = rpc.MetaRef
+)
+
+// FormatTime formats t as a metadata value: RFC 3339 in UTC, or "" for the
+// zero time.
+func FormatTime(t time.Time) string {
+	return rpc.FormatTime(t)
+}
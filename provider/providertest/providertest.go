@@ -0,0 +1,189 @@
+// Package providertest runs provider handlers over in-memory pipes so plugin
+// tests exercise the same framing, option encoding and error reporting as sfx.
+package providertest
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+	"github.com/fr0stylo/sfx/provider"
+)
+
+// Error is an error reported by the handler in a response, as opposed to a
+// failure of the transport.
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Plugin is a provider handler served over in-memory pipes.
+type Plugin struct {
+	t         testing.TB
+	codec     rpc.Codec
+	in        *io.PipeWriter
+	out       *bufio.Reader
+	handshake *rpc.Handshake
+
+	mu sync.Mutex
+}
+
+// Start serves h with the default protobuf framing and reads its handshake. The
+// plugin is shut down when the test ends.
+func Start(t testing.TB, h provider.Handler, opts ...provider.Option) *Plugin {
+	t.Helper()
+	return StartProtocol(t, rpc.ProtocolProtobuf, h, opts...)
+}
+
+// StartProtocol is like Start but uses the named wire protocol ("protobuf" or "jsonl").
+func StartProtocol(t testing.TB, protocol string, h provider.Handler, opts ...provider.Option) *Plugin {
+	t.Helper()
+
+	codec, err := rpc.CodecFor(protocol)
+	if err != nil {
+		t.Fatalf("providertest: %v", err)
+	}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := provider.ServeStream(protocol, inR, outW, h, opts...)
+		_ = outW.CloseWithError(io.EOF)
+		done <- err
+	}()
+
+	p := &Plugin{t: t, codec: codec, in: inW, out: bufio.NewReader(outR)}
+	t.Cleanup(func() {
+		_ = inW.Close()
+		_ = outR.Close()
+		if err := <-done; err != nil {
+			t.Errorf("providertest: serve: %v", err)
+		}
+	})
+
+	hs, err := rpc.ReadHandshake(codec, p.out)
+	if err != nil {
+		t.Fatalf("providertest: %v", err)
+	}
+	if hs.GetKind() != "provider" {
+		t.Fatalf("providertest: handshake kind %q, want provider", hs.GetKind())
+	}
+	p.handshake = hs
+	return p
+}
+
+// Mode returns the execution mode advertised in the handshake.
+func (p *Plugin) Mode() string {
+	return p.handshake.GetMode()
+}
+
+// RefFormat returns the ref grammar advertised in the handshake.
+func (p *Plugin) RefFormat() string {
+	return p.handshake.GetRefFormat()
+}
+
+// OptionsSchema returns the JSON Schema advertised for the plugin's options.
+func (p *Plugin) OptionsSchema() []byte {
+	return p.handshake.GetOptionsSchema()
+}
+
+// SupportsCheck reports whether the handshake advertises check support, which
+// handlers implementing provider.Checker do.
+func (p *Plugin) SupportsCheck() bool {
+	return p.handshake.GetCheck()
+}
+
+// Fetch resolves ref with options encoded as sfx encodes provider_options.
+// Errors reported by the handler are returned as *Error; transport failures
+// fail the test.
+func (p *Plugin) Fetch(ref string, options map[string]any) ([]byte, error) {
+	p.t.Helper()
+	value, _, err := p.FetchMetadata(ref, options)
+	return value, err
+}
+
+// FetchMetadata is like Fetch but also returns the metadata the plugin
+// reported for the value.
+func (p *Plugin) FetchMetadata(ref string, options map[string]any) ([]byte, map[string]string, error) {
+	p.t.Helper()
+	resp := p.call(&rpc.SecretRequest{Ref: ref, Options: p.options(options)})
+	if resp.GetError() != "" {
+		return nil, nil, &Error{Message: resp.GetError()}
+	}
+	return resp.GetValue(), resp.GetMetadata(), nil
+}
+
+// Check asks the plugin to confirm that ref is reachable, as sfx verify --deep does.
+func (p *Plugin) Check(ref string, options map[string]any) error {
+	p.t.Helper()
+	resp := p.call(&rpc.SecretRequest{Ref: ref, Options: p.options(options), Check: true})
+	if resp.GetError() != "" {
+		return &Error{Message: resp.GetError()}
+	}
+	return nil
+}
+
+// ExpectValue fails the test unless ref resolves to want.
+func (p *Plugin) ExpectValue(ref string, options map[string]any, want string) {
+	p.t.Helper()
+	got, err := p.Fetch(ref, options)
+	if err != nil {
+		p.t.Errorf("Fetch(%q) returned error: %v", ref, err)
+		return
+	}
+	if string(got) != want {
+		p.t.Errorf("Fetch(%q) = %q, want %q", ref, got, want)
+	}
+}
+
+// ExpectError fails the test unless the handler reports an error for ref whose
+// message contains substr.
+func (p *Plugin) ExpectError(ref string, options map[string]any, substr string) {
+	p.t.Helper()
+	_, err := p.Fetch(ref, options)
+	var perr *Error
+	if !errors.As(err, &perr) {
+		p.t.Errorf("Fetch(%q) succeeded, want error containing %q", ref, substr)
+		return
+	}
+	if !strings.Contains(perr.Message, substr) {
+		p.t.Errorf("Fetch(%q) error %q does not contain %q", ref, perr.Message, substr)
+	}
+}
+
+func (p *Plugin) options(options map[string]any) []byte {
+	p.t.Helper()
+	raw, err := rpc.MarshalOptions(options)
+	if err != nil {
+		p.t.Fatalf("providertest: marshal options: %v", err)
+	}
+	return raw
+}
+
+func (p *Plugin) call(req *rpc.SecretRequest) *rpc.SecretResponse {
+	p.t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.codec.WriteMessage(p.in, req); err != nil {
+		p.t.Fatalf("providertest: send request: %v", err)
+	}
+	resp := &rpc.SecretResponse{}
+	if err := p.codec.ReadMessage(p.out, resp); err != nil {
+		p.t.Fatalf("providertest: read response: %v", err)
+	}
+	return resp
+}
+
+// MarshalOptions encodes options the way sfx sends provider_options to plugins.
+func MarshalOptions(options map[string]any) ([]byte, error) {
+	return rpc.MarshalOptions(options)
+}
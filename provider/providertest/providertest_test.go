@@ -0,0 +1,137 @@
+package providertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+	"github.com/fr0stylo/sfx/provider"
+)
+
+type echoOptions struct {
+	Prefix string `yaml:"prefix"`
+}
+
+var echo = provider.HandlerFunc(func(_ context.Context, req provider.Request) (provider.Response, error) {
+	opts, err := provider.DecodeOptions[echoOptions](req.Options)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	if req.Ref == "missing" {
+		return provider.Response{}, fmt.Errorf("ref %q not found", req.Ref)
+	}
+	return provider.Response{
+		Value:    []byte(opts.Prefix + req.Ref),
+		Metadata: map[string]string{provider.MetaVersion: "1"},
+	}, nil
+})
+
+func TestPluginRoundTrip(t *testing.T) {
+	for _, protocol := range []string{rpc.ProtocolProtobuf, rpc.ProtocolJSONL} {
+		t.Run(protocol, func(t *testing.T) {
+			p := StartProtocol(t, protocol, echo, provider.WithMode(provider.ModeOneShot), provider.WithRefFormat("<name>"))
+
+			if p.Mode() != "oneshot" || p.RefFormat() != "<name>" {
+				t.Fatalf("handshake = (%q, %q), want (oneshot, <name>)", p.Mode(), p.RefFormat())
+			}
+			p.ExpectValue("db", map[string]any{"prefix": "v-"}, "v-db")
+			p.ExpectValue("db", nil, "db")
+			p.ExpectError("missing", nil, "not found")
+
+			if _, md, err := p.FetchMetadata("db", nil); err != nil || md[provider.MetaVersion] != "1" {
+				t.Fatalf("FetchMetadata = (%v, %v), want version 1", md, err)
+			}
+
+			if p.SupportsCheck() {
+				t.Fatal("SupportsCheck = true for a handler without Check")
+			}
+			if err := p.Check("db", nil); err == nil || !strings.Contains(err.Error(), "cannot check refs") {
+				t.Fatalf("Check = %v, want an unsupported check error", err)
+			}
+		})
+	}
+}
+
+// checkingEcho confirms refs without producing their values.
+type checkingEcho struct {
+	provider.HandlerFunc
+	checked []string
+}
+
+func (h *checkingEcho) Check(_ context.Context, req provider.Request) error {
+	h.checked = append(h.checked, req.Ref)
+	if req.Ref == "missing" {
+		return fmt.Errorf("ref %q not found", req.Ref)
+	}
+	return nil
+}
+
+func TestPluginChecksWithChecker(t *testing.T) {
+	h := &checkingEcho{HandlerFunc: echo}
+	p := Start(t, h)
+
+	if !p.SupportsCheck() {
+		t.Fatal("SupportsCheck = false for a provider.Checker")
+	}
+	if err := p.Check("db", nil); err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	var perr *Error
+	if err := p.Check("missing", nil); !errors.As(err, &perr) {
+		t.Fatalf("Check(missing) = %v, want *Error", err)
+	}
+	if len(h.checked) != 2 {
+		t.Fatalf("Check called for %v, want db and missing", h.checked)
+	}
+}
+
+func TestPluginReportsOptionDecodeErrors(t *testing.T) {
+	p := Start(t, echo)
+	p.ExpectError("db", map[string]any{"prefix": []string{"a"}}, "prefix: cannot unmarshal")
+	p.ExpectError("db", map[string]any{"suffix": "x"}, `unknown option "suffix"`)
+}
+
+type lifecycleHandler struct {
+	inits    int
+	failInit bool
+	shutdown bool
+}
+
+func (h *lifecycleHandler) Init(context.Context) error {
+	h.inits++
+	if h.failInit {
+		h.failInit = false
+		return errors.New("no credentials")
+	}
+	return nil
+}
+
+func (h *lifecycleHandler) Handle(ctx context.Context, req provider.Request) (provider.Response, error) {
+	return provider.Response{Value: []byte(req.Ref)}, ctx.Err()
+}
+
+func (h *lifecycleHandler) Shutdown(context.Context) error {
+	h.shutdown = true
+	return nil
+}
+
+func TestPluginRunsLifecycleHooks(t *testing.T) {
+	h := &lifecycleHandler{failInit: true}
+
+	t.Run("serve", func(t *testing.T) {
+		p := Start(t, h)
+		p.ExpectError("a", nil, "init: no credentials")
+		p.ExpectValue("a", nil, "a")
+		p.ExpectValue("b", nil, "b")
+	})
+
+	if h.inits != 2 {
+		t.Fatalf("Init called %d times, want 2", h.inits)
+	}
+	if !h.shutdown {
+		t.Fatalf("Shutdown not called after EOF")
+	}
+}
@@ -0,0 +1,134 @@
+// Package ref parses the ref grammar shared by the bundled providers:
+//
+//	[scheme://]path[?query][#fragment]
+//
+// The fragment names a field inside the secret, or selects a version or stage
+// with a "version:" or "stage:" prefix ("version_id:" and "version_stage:" are
+// accepted as aliases). Surrounding whitespace is ignored and every component is
+// percent-decoded, so a literal '#', '?' or '%' in a path is written as %23, %3F
+// or %25, and a field that starts with "version:" as "version%3A...".
+package ref
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Ref is a parsed provider ref.
+type Ref struct {
+	// Scheme is the part before "://", e.g. "env" in "env://API_KEY".
+	Scheme string
+	// Path identifies the secret: a file, a Vault path, a secret name.
+	Path string
+	// Field selects a value inside the secret.
+	Field string
+	// Version selects a secret version.
+	Version string
+	// Stage selects a version by label, e.g. AWSCURRENT.
+	Stage string
+	// Query holds provider-specific parameters.
+	Query url.Values
+}
+
+var schemePattern = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*)://`)
+
+// Parse splits s into its components.
+func Parse(s string) (Ref, error) {
+	var r Ref
+	rest := strings.TrimSpace(s)
+
+	if m := schemePattern.FindStringSubmatch(rest); m != nil {
+		r.Scheme = m[1]
+		rest = rest[len(m[0]):]
+	}
+
+	rest, fragment, _ := strings.Cut(rest, "#")
+	rest, query, hasQuery := strings.Cut(rest, "?")
+
+	var err error
+	if r.Path, err = unescape(s, "path", strings.TrimSpace(rest)); err != nil {
+		return Ref{}, err
+	}
+	if hasQuery {
+		if r.Query, err = url.ParseQuery(strings.TrimSpace(query)); err != nil {
+			return Ref{}, fmt.Errorf("ref %q: invalid query: %w", s, err)
+		}
+	}
+
+	fragment = strings.TrimSpace(fragment)
+	key, value, ok := strings.Cut(fragment, ":")
+	target := &r.Field
+	if ok {
+		switch key {
+		case "version", "version_id":
+			target, fragment = &r.Version, value
+		case "stage", "version_stage":
+			target, fragment = &r.Stage, value
+		}
+	}
+	if *target, err = unescape(s, "fragment", strings.TrimSpace(fragment)); err != nil {
+		return Ref{}, err
+	}
+
+	return r, nil
+}
+
+// String formats r in the canonical grammar, escaping where needed.
+func (r Ref) String() string {
+	var b strings.Builder
+	if r.Scheme != "" {
+		b.WriteString(r.Scheme)
+		b.WriteString("://")
+	}
+	b.WriteString(escape(r.Path, "#?%"))
+	if len(r.Query) > 0 {
+		b.WriteByte('?')
+		b.WriteString(r.Query.Encode())
+	}
+	switch {
+	case r.Version != "":
+		b.WriteString("#version:")
+		b.WriteString(escape(r.Version, "#%"))
+	case r.Stage != "":
+		b.WriteString("#stage:")
+		b.WriteString(escape(r.Stage, "#%"))
+	case r.Field != "":
+		b.WriteByte('#')
+		field := escape(r.Field, "#%")
+		if k, _, ok := strings.Cut(field, ":"); ok && isSelector(k) {
+			field = strings.Replace(field, ":", "%3A", 1)
+		}
+		b.WriteString(field)
+	}
+	return b.String()
+}
+
+func isSelector(key string) bool {
+	switch key {
+	case "version", "version_id", "stage", "version_stage":
+		return true
+	}
+	return false
+}
+
+func unescape(ref, part, s string) (string, error) {
+	out, err := url.PathUnescape(s)
+	if err != nil {
+		return "", fmt.Errorf("ref %q: invalid escape in %s: %w", ref, part, err)
+	}
+	return out, nil
+}
+
+func escape(s, chars string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(chars, c) {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package ref
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Ref
+	}{
+		{in: "secret/data/app", want: Ref{Path: "secret/data/app"}},
+		{in: "  secret/data/app # password ", want: Ref{Path: "secret/data/app", Field: "password"}},
+		{in: "env://API_KEY", want: Ref{Scheme: "env", Path: "API_KEY"}},
+		{in: "my-secret#version:abc123", want: Ref{Path: "my-secret", Version: "abc123"}},
+		{in: "my-secret#version_id: abc456", want: Ref{Path: "my-secret", Version: "abc456"}},
+		{in: "my-secret#stage:AWSPREVIOUS", want: Ref{Path: "my-secret", Stage: "AWSPREVIOUS"}},
+		{in: "my-secret#version_stage:AWSCURRENT", want: Ref{Path: "my-secret", Stage: "AWSCURRENT"}},
+		{in: "secret/data/app?version=2#password", want: Ref{Path: "secret/data/app", Field: "password", Query: url.Values{"version": {"2"}}}},
+		{in: "files/a%23b.yaml#db.password", want: Ref{Path: "files/a#b.yaml", Field: "db.password"}},
+		{in: "app#stage%3Aname", want: Ref{Path: "app", Field: "stage:name"}},
+		{in: "app#url:https://x", want: Ref{Path: "app", Field: "url:https://x"}},
+		{in: "https://v.vault.azure.net/secrets/db", want: Ref{Scheme: "https", Path: "v.vault.azure.net/secrets/db"}},
+		{in: "  ", want: Ref{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if err != nil {
+				t.Fatalf("Parse returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidEscapes(t *testing.T) {
+	for _, in := range []string{"100%", "app#50%", "app?a=%zz"} {
+		if _, err := Parse(in); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("Parse(%q) error = %v, want invalid escape", in, err)
+		}
+	}
+}
+
+func TestStringRoundTrips(t *testing.T) {
+	for _, in := range []string{
+		"secret/data/app",
+		"env://API_KEY",
+		"files/a%23b%3F.yaml#db.password",
+		"app?version=2#version:3",
+		"app#stage:AWSCURRENT",
+		"app#stage%3Aname",
+		"app#50%25",
+	} {
+		r, err := Parse(in)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", in, err)
+		}
+		if got := r.String(); got != in {
+			t.Errorf("Parse(%q).String() = %q", in, got)
+		}
+	}
+}
@@ -2,8 +2,14 @@
 package provider
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/fr0stylo/sfx/internal/rpc"
 )
@@ -17,42 +23,167 @@ type Request struct {
 // Response is the simplified output expected from plugin handlers.
 type Response struct {
 	Value []byte
+	// Metadata describes Value as reported by the backend, using the Meta*
+	// keys where they apply. It is optional.
+	Metadata map[string]string
 }
 
 // Handler processes a single Request and returns the corresponding Response.
+// ctx is cancelled when the host abandons the request: plugins started by the
+// host receive SIGTERM (WASM modules are not interrupted), under Serve the HTTP
+// request ends, and builtins get the host's own context. Handlers should pass
+// it to the API calls they make. Handlers may also implement Initializer and
+// Shutdowner to manage state shared across requests.
 type Handler interface {
-	Handle(Request) (Response, error)
+	Handle(ctx context.Context, req Request) (Response, error)
+}
+
+// Checker is implemented by handlers that can confirm a ref exists and is readable
+// without retrieving its value, typically through a metadata API. Plugins
+// advertise it in the handshake; check requests to handlers that do not
+// implement it fail rather than fetch the value.
+type Checker interface {
+	Check(ctx context.Context, req Request) error
 }
 
 // HandlerFunc adapts a function to the Handler interface.
-type HandlerFunc func(Request) (Response, error)
+type HandlerFunc func(context.Context, Request) (Response, error)
+
+// Handle calls f(ctx, req).
+func (f HandlerFunc) Handle(ctx context.Context, req Request) (Response, error) {
+	return f(ctx, req)
+}
+
+// HandlerFuncs adapts a pair of functions to a Handler that also implements
+// Checker, for providers written as plain functions. Both must be set.
+type HandlerFuncs struct {
+	HandleFunc HandlerFunc
+	CheckFunc  func(context.Context, Request) error
+}
+
+// Handle calls f.HandleFunc(ctx, req).
+func (f HandlerFuncs) Handle(ctx context.Context, req Request) (Response, error) {
+	return f.HandleFunc(ctx, req)
+}
+
+// Check calls f.CheckFunc(ctx, req).
+func (f HandlerFuncs) Check(ctx context.Context, req Request) error {
+	return f.CheckFunc(ctx, req)
+}
+
+// Run wires stdin/stdout to the plugin transport and invokes the provided handler.
+// The wire protocol (length-delimited protobuf or JSON lines) is chosen by the host.
+// When started outside the host with SFX_PLUGIN_LISTEN set, it calls Serve on
+// that address instead.
+func Run(h Handler, opts ...Option) {
+	if err := run(h, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+}
+
+func run(h Handler, opts []Option) error {
+	if addr := rpc.HostListenAddr(); addr != "" && !rpc.HostManaged() {
+		return listenAndServe(addr, h, opts)
+	}
+
+	codec, err := rpc.HostCodec()
+	if err != nil {
+		return err
+	}
+
+	// The host sends SIGTERM when it abandons the request in flight.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+	return serve(ctx, codec, bufio.NewReader(os.Stdin), os.Stdout, h, newSettings(opts))
+}
 
-// Handle calls f(req).
-func (f HandlerFunc) Handle(req Request) (Response, error) {
-	return f(req)
+func serve(ctx context.Context, c rpc.Codec, in io.Reader, out io.Writer, h Handler, s settings) error {
+	hs, err := s.handshake(h)
+	if err != nil {
+		return err
+	}
+	if err := rpc.WriteHandshake(c, out, hs); err != nil {
+		return fmt.Errorf("write handshake: %w", err)
+	}
+	return serveRequests(ctx, c, in, out, newLifecycle(h))
 }
 
-// Run wires stdin/stdout to the protobuf transport and invokes the provided handler.
-func Run(h Handler) {
+// ServeStream speaks the host's stdio protocol on in and out until in is
+// exhausted: it writes the handshake, then answers every request with h.
+// protocol selects the framing ("protobuf", the default, or "jsonl"). Unlike Run
+// it always announces itself, which lets tests and custom transports drive a
+// handler exactly as the host would. Requests read before in is exhausted are
+// still answered.
+func ServeStream(protocol string, in io.Reader, out io.Writer, h Handler, opts ...Option) error {
+	c, err := rpc.CodecFor(protocol)
+	if err != nil {
+		return err
+	}
+	hs, err := newSettings(opts).handshake(h)
+	if err != nil {
+		return err
+	}
+	hs.ProtocolVersion = rpc.ProtocolVersion
+	if err := c.WriteMessage(out, hs); err != nil {
+		return fmt.Errorf("write handshake: %w", err)
+	}
+	return serveRequests(context.Background(), c, in, out, newLifecycle(h))
+}
+
+// serveRequests answers requests until in is exhausted, then runs the handler's
+// Shutdown hook. Requests already read run to completion; ctx cancels them.
+func serveRequests(ctx context.Context, c rpc.Codec, in io.Reader, out io.Writer, l *lifecycle) (err error) {
+	defer func() {
+		err = errors.Join(err, l.shutdown(context.Background()))
+	}()
+
 	for {
 		req := &rpc.SecretRequest{}
-		if err := rpc.ReadDelimited(os.Stdin, req); err != nil {
-			writeError(fmt.Errorf("decode request: %w", err))
-			return
+		if err := c.ReadMessage(in, req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			writeError(c, out, fmt.Errorf("decode request: %w", err))
+			return nil
 		}
 
-		resp, err := h.Handle(Request{Ref: req.GetRef(), Options: req.GetOptions()})
+		resp, err := dispatch(ctx, l, req)
+		rpc.WriteStderrSync(os.Stderr)
 		if err != nil {
-			writeError(err)
-			return
+			writeError(c, out, err)
+			continue
 		}
 
-		if err := rpc.WriteDelimited(os.Stdout, &rpc.SecretResponse{Value: resp.Value}); err != nil {
-			fmt.Fprintf(os.Stderr, "write response: %v\n", err)
+		if err := c.WriteMessage(out, resp); err != nil {
+			return fmt.Errorf("write response: %w", err)
 		}
 	}
 }
 
-func writeError(err error) {
-	_ = rpc.WriteDelimited(os.Stdout, &rpc.SecretResponse{Error: err.Error()})
+func dispatch(ctx context.Context, l *lifecycle, req *rpc.SecretRequest) (*rpc.SecretResponse, error) {
+	if err := l.init(ctx); err != nil {
+		return nil, err
+	}
+	h := l.h
+	r := Request{Ref: req.GetRef(), Options: req.GetOptions()}
+
+	if req.GetCheck() {
+		c, ok := h.(Checker)
+		if !ok {
+			return nil, errCheckUnsupported
+		}
+		return &rpc.SecretResponse{}, c.Check(ctx, r)
+	}
+
+	resp, err := h.Handle(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.SecretResponse{Value: resp.Value, Metadata: resp.Metadata}, nil
+}
+
+var errCheckUnsupported = errors.New("provider cannot check refs without retrieving their values")
+
+func writeError(c rpc.Codec, w io.Writer, err error) {
+	_ = c.WriteMessage(w, &rpc.SecretResponse{Error: err.Error()})
 }
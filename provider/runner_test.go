@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+func TestServeStreamContinuesAfterHandlerError(t *testing.T) {
+	h := HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+		if req.Ref == "missing" {
+			return Response{}, errors.New("secret not found")
+		}
+		return Response{Value: []byte("v-" + req.Ref)}, nil
+	})
+
+	c, _ := rpc.CodecFor(rpc.ProtocolProtobuf)
+	var in, out bytes.Buffer
+	for _, ref := range []string{"a", "missing", "b"} {
+		if err := c.WriteMessage(&in, &rpc.SecretRequest{Ref: ref}); err != nil {
+			t.Fatalf("WriteMessage returned error: %v", err)
+		}
+	}
+	if err := ServeStream(rpc.ProtocolProtobuf, &in, &out, h); err != nil {
+		t.Fatalf("ServeStream returned error: %v", err)
+	}
+
+	if _, err := rpc.ReadHandshake(c, &out); err != nil {
+		t.Fatalf("ReadHandshake returned error: %v", err)
+	}
+	want := []*rpc.SecretResponse{{Value: []byte("v-a")}, {Error: "secret not found"}, {Value: []byte("v-b")}}
+	for i, w := range want {
+		got := &rpc.SecretResponse{}
+		if err := c.ReadMessage(&out, got); err != nil {
+			t.Fatalf("response %d: ReadMessage returned error: %v", i+1, err)
+		}
+		if string(got.GetValue()) != string(w.GetValue()) || got.GetError() != w.GetError() {
+			t.Fatalf("response %d = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestServeStreamAnswersRequestPipedBeforeEOF(t *testing.T) {
+	started := make(chan struct{})
+	h := HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			return Response{}, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return Response{Value: []byte("v-" + req.Ref)}, nil
+		}
+	})
+
+	c, _ := rpc.CodecFor(rpc.ProtocolProtobuf)
+	inR, inW := io.Pipe()
+	var out bytes.Buffer
+	done := make(chan error, 1)
+	go func() { done <- ServeStream(rpc.ProtocolProtobuf, inR, &out, h) }()
+
+	if err := c.WriteMessage(inW, &rpc.SecretRequest{Ref: "piped"}); err != nil {
+		t.Fatalf("WriteMessage returned error: %v", err)
+	}
+	_ = inW.Close()
+	<-started
+	if err := <-done; err != nil {
+		t.Fatalf("ServeStream returned error: %v", err)
+	}
+
+	if _, err := rpc.ReadHandshake(c, &out); err != nil {
+		t.Fatalf("ReadHandshake returned error: %v", err)
+	}
+	got := &rpc.SecretResponse{}
+	if err := c.ReadMessage(&out, got); err != nil {
+		t.Fatalf("ReadMessage returned error: %v", err)
+	}
+	if got.GetError() != "" || string(got.GetValue()) != "v-piped" {
+		t.Fatalf("response = %v, want value %q", got, "v-piped")
+	}
+}
+
+func TestServeCancelsRequestWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := HandlerFunc(func(ctx context.Context, _ Request) (Response, error) {
+		cancel()
+		<-ctx.Done()
+		return Response{}, ctx.Err()
+	})
+
+	c, _ := rpc.CodecFor(rpc.ProtocolProtobuf)
+	var in, out bytes.Buffer
+	if err := c.WriteMessage(&in, &rpc.SecretRequest{Ref: "slow"}); err != nil {
+		t.Fatalf("WriteMessage returned error: %v", err)
+	}
+	if err := serve(ctx, c, &in, &out, h, newSettings(nil)); err != nil {
+		t.Fatalf("serve returned error: %v", err)
+	}
+
+	// Outside the host no handshake precedes the responses.
+	got := &rpc.SecretResponse{}
+	if err := c.ReadMessage(&out, got); err != nil {
+		t.Fatalf("ReadMessage returned error: %v", err)
+	}
+	if got.GetError() != context.Canceled.Error() {
+		t.Fatalf("response error = %q, want %q", got.GetError(), context.Canceled.Error())
+	}
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+// Serve answers requests from remote sfx hosts on l until l is closed. It is the
+// long-lived counterpart to Run: hosts reach it through a unix:// or http://
+// plugin path. Requests may arrive concurrently, so h must be safe for concurrent
+// use. Each request's context ends when its HTTP request does; the handler's
+// Shutdown hook runs once l is closed and the requests in flight have
+// completed.
+func Serve(l net.Listener, h Handler, opts ...Option) error {
+	hs, err := newSettings(opts).handshake(h)
+	if err != nil {
+		return err
+	}
+	hs.ProtocolVersion = rpc.ProtocolVersion
+	hs.StderrSync = false
+	lc := newLifecycle(h)
+
+	srv := &http.Server{
+		Handler: rpc.HTTPHandler{
+			Handshake:  hs,
+			NewRequest: func() proto.Message { return &rpc.SecretRequest{} },
+			Call: func(r *http.Request, req proto.Message) proto.Message {
+				resp, err := dispatch(r.Context(), lc, req.(*rpc.SecretRequest))
+				if err != nil {
+					return &rpc.SecretResponse{Error: err.Error()}
+				}
+				return resp
+			},
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	err = srv.Serve(l)
+	if errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+	// Serve returns as soon as l is closed; wait for the requests still in
+	// flight before the Shutdown hook releases what they may be using.
+	if serr := srv.Shutdown(context.Background()); serr != nil && !errors.Is(serr, net.ErrClosed) {
+		err = errors.Join(err, serr)
+	}
+	return errors.Join(err, lc.shutdown(context.Background()))
+}
+
+// listenAndServe serves h on the address requested through SFX_PLUGIN_LISTEN.
+func listenAndServe(addr string, h Handler, opts []Option) error {
+	l, err := rpc.Listen(addr)
+	if err != nil {
+		return err
+	}
+	return Serve(l, h, opts...)
+}
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fr0stylo/sfx/internal/rpc"
+)
+
+type drainHandler struct {
+	started  chan struct{}
+	release  chan struct{}
+	shutdown atomic.Bool
+}
+
+func (h *drainHandler) Handle(context.Context, Request) (Response, error) {
+	close(h.started)
+	<-h.release
+	if h.shutdown.Load() {
+		return Response{}, nil
+	}
+	return Response{Value: []byte("served")}, nil
+}
+
+func (h *drainHandler) Shutdown(context.Context) error {
+	h.shutdown.Store(true)
+	return nil
+}
+
+func TestServeWaitsForRequestsInFlight(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	h := &drainHandler{started: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan error, 1)
+	go func() { done <- Serve(l, h) }()
+
+	c, _ := rpc.CodecFor(rpc.ProtocolJSONL)
+	var body bytes.Buffer
+	if err := c.WriteMessage(&body, &rpc.SecretRequest{Ref: "slow"}); err != nil {
+		t.Fatalf("WriteMessage returned error: %v", err)
+	}
+	type result struct {
+		resp *rpc.SecretResponse
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		r, err := http.Post("http://"+l.Addr().String()+rpc.CallPath, rpc.ContentType(rpc.ProtocolJSONL), &body)
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer func() { _ = r.Body.Close() }()
+		resp := &rpc.SecretResponse{}
+		results <- result{resp: resp, err: c.ReadMessage(r.Body, resp)}
+	}()
+
+	<-h.started
+	_ = l.Close()
+	select {
+	case err := <-done:
+		t.Fatalf("Serve returned %v with a request in flight", err)
+	default:
+	}
+	close(h.release)
+
+	res := <-results
+	if res.err != nil {
+		t.Fatalf("call returned error: %v", res.err)
+	}
+	if got := string(res.resp.GetValue()); got != "served" {
+		t.Fatalf("value = %q, want %q (Shutdown ran before the request completed)", got, "served")
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned error: %v", err)
+	}
+	if !h.shutdown.Load() {
+		t.Fatal("Shutdown hook did not run")
+	}
+}